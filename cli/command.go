@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// command struct defines a command line subcommand, e.g. "toggl export".
+type command struct {
+	group       string
+	name        string
+	description string
+	// setup registers the command flags and returns the function that validates the flags and runs the command.
+	setup func(commandLine *CommandLine, flagSet *flag.FlagSet) func() error
+}
+
+// UsageError defines the command line usage error.
+type UsageError struct {
+	Command string
+	Message string
+}
+
+func (err *UsageError) Error() string {
+	if err.Command == "" {
+		return err.Message
+	}
+	return fmt.Sprintf("%s: %s", err.Command, err.Message)
+}
+
+// path returns the full command name, e.g. "toggl export".
+func (command command) path() string {
+	return command.group + " " + command.name
+}
+
+// newUsageError creates a new UsageError for the flag validation errors.
+func newUsageError(format string, a ...interface{}) error {
+	return &UsageError{Message: fmt.Sprintf(format, a...)}
+}
+
+// newFlagSet creates the command flag set with the command usage text.
+func (commandLine *CommandLine) newFlagSet(command command) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(command.path(), flag.ContinueOnError)
+	flagSet.SetOutput(commandLine.output)
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage: %s %s [flags]\n\n%s\n", applicationName, command.path(), command.description)
+		hasFlags := false
+		flagSet.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(flagSet.Output(), "\nFlags:\n")
+			flagSet.PrintDefaults()
+		}
+	}
+	return flagSet
+}
+
+// executeCommand parses the command flags and runs the command.
+func (commandLine *CommandLine) executeCommand(command command, arguments []string) error {
+	flagSet := commandLine.newFlagSet(command)
+	run := command.setup(commandLine, flagSet)
+	err := flagSet.Parse(arguments)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return &UsageError{Command: command.path(), Message: err.Error()}
+	}
+	if flagSet.NArg() > 0 {
+		err = newUsageError("unexpected arguments: %s", strings.Join(flagSet.Args(), " "))
+	} else {
+		err = run()
+	}
+	if usageError, ok := err.(*UsageError); ok {
+		usageError.Command = command.path()
+		fmt.Fprintf(flagSet.Output(), "%s\n", usageError.Message)
+		flagSet.Usage()
+	}
+	return err
+}
+
+// requireFlag verifies that a string flag has a value.
+func requireFlag(name string, value string) error {
+	if value == "" {
+		return newUsageError("the -%s flag is required", name)
+	}
+	return nil
+}
+
+// validateTableName verifies that the database table is one of the tables managed by the application.
+func validateTableName(databaseTableName string) error {
+	if err := requireFlag("table", databaseTableName); err != nil {
+		return err
+	}
+	if databaseTableName != "toggl_time" && databaseTableName != "trello_card" {
+		return newUsageError("invalid table %q, choose from 'toggl_time' and 'trello_card'", databaseTableName)
+	}
+	return nil
+}
+
+// splitList splits a comma separated flag value, ignoring the empty values.
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -3,23 +3,47 @@ package cli
 import (
 	"flag"
 	"fmt"
-	"strconv"
+	"io"
+	"net/http"
+	"os"
 	"strings"
-	"time"
 
-	trelloLib "github.com/adlio/trello"
 	"github.com/sitMCella/toggl-trello-kpi/configuration"
-	"github.com/sitMCella/toggl-trello-kpi/grafana"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
 	"github.com/sitMCella/toggl-trello-kpi/storage"
-	"github.com/sitMCella/toggl-trello-kpi/toggl"
-	"github.com/sitMCella/toggl-trello-kpi/trello"
 	"go.uber.org/zap"
 )
 
+const applicationName = "toggl-trello-kpi"
+
 // CommandLine struct defines the command line service.
 type CommandLine struct {
-	config configuration.Configuration
-	logger *zap.Logger
+	config   configuration.Configuration
+	logger   *zap.Logger
+	output   io.Writer
+	commands []command
+}
+
+// deprecatedChoice struct defines the subcommand that replaces a numeric -choice value.
+type deprecatedChoice struct {
+	group string
+	name  string
+	// positionalFlags lists the flag names of the positional arguments accepted by the -choice value.
+	positionalFlags []string
+	// translate converts the positional arguments into flags, when they do not map one to one.
+	translate func(arguments []string) []string
+}
+
+// deprecatedChoices maps the deprecated -choice values to the subcommands.
+var deprecatedChoices = map[int]deprecatedChoice{
+	1: {group: "toggl", name: "export", translate: translateYearAndMonth},
+	2: {group: "trello", name: "export"},
+	3: {group: "db", name: "import", positionalFlags: []string{"file", "table"}},
+	4: {group: "toggl", name: "sync"},
+	5: {group: "trello", name: "sync"},
+	6: {group: "db", name: "export", positionalFlags: []string{"table", "columns"}},
+	7: {group: "db", name: "update", positionalFlags: []string{"file", "table", "column"}},
+	8: {group: "grafana", name: "dashboard"},
 }
 
 // NewCommandLine creates a new CommandLine.
@@ -27,222 +51,112 @@ func NewCommandLine(config configuration.Configuration, logger *zap.Logger) Comm
 	commandLine := CommandLine{
 		config: config,
 		logger: logger,
+		output: os.Stderr,
+		commands: []command{
+			{group: "toggl", name: "export", description: "Download the Toggl time entries as CSV file.", setup: (*CommandLine).togglExportCommand},
+			{group: "toggl", name: "sync", description: "Download and store the Toggl time entries in the database.", setup: (*CommandLine).togglSyncCommand},
+			{group: "toggl", name: "link", description: "Link the Toggl time entries stored in the database to the Trello cards.", setup: (*CommandLine).togglLinkCommand},
+			{group: "toggl", name: "reconcile", description: "Report or remove the Toggl time entries stored in the database but deleted in Toggl.", setup: (*CommandLine).togglReconcileCommand},
+			{group: "trello", name: "export", description: "Download the Trello cards as CSV file.", setup: (*CommandLine).trelloExportCommand},
+			{group: "trello", name: "sync", description: "Download and store the Trello cards in the database.", setup: (*CommandLine).trelloSyncCommand},
+			{group: "trello", name: "transitions", description: "Download and store the Trello card transitions between lists in the database.", setup: (*CommandLine).trelloTransitionsCommand},
+			{group: "trello", name: "cycle-time", description: "Download the lead time and the cycle time of the done Trello cards from the database to a CSV file.", setup: (*CommandLine).trelloCycleTimeCommand},
+			{group: "trello", name: "estimation", description: "Download the estimation accuracy of the estimated Trello cards from the database to CSV files.", setup: (*CommandLine).trelloEstimationCommand},
+			{group: "db", name: "import", description: "Insert either the Toggl time entries or the Trello cards into the database from a CSV file.", setup: (*CommandLine).databaseImportCommand},
+			{group: "db", name: "export", description: "Download either the Toggl time entries or the Trello cards from the database to a CSV file.", setup: (*CommandLine).databaseExportCommand},
+			{group: "db", name: "update", description: "Update a database table column from a CSV file.", setup: (*CommandLine).databaseUpdateCommand},
+			{group: "migrate", name: "up", description: "Apply the pending database migrations.", setup: (*CommandLine).migrateUpCommand},
+			{group: "migrate", name: "down", description: "Revert the last applied database migrations.", setup: (*CommandLine).migrateDownCommand},
+			{group: "migrate", name: "status", description: "Print the state of the database migrations.", setup: (*CommandLine).migrateStatusCommand},
+			{group: "grafana", name: "dashboard", description: "Create the Grafana dashboard from the application configuration.", setup: (*CommandLine).grafanaDashboardCommand},
+		},
 	}
 	return commandLine
 }
 
-// Execute runs a command based on the command line choice and parameters.
-func (commandLine *CommandLine) Execute() {
-	executionChoice := flag.Int("choice", 1, "Application execution choice")
-	flag.Parse()
-
-	switch choice := *executionChoice; choice {
-	case 1:
-		commandLine.downloadTogglTimeAsCsv(flag.Args())
-	case 2:
-		commandLine.downloadTrelloCardsAsCsv()
-	case 3:
-		commandLine.insertFromCsv(flag.Args())
-	case 4:
-		commandLine.storeTogglTime()
-	case 5:
-		commandLine.storeTrelloBoard()
-	case 6:
-		commandLine.downloadTableAsCsv(flag.Args())
-	case 7:
-		commandLine.updateFromCsv(flag.Args())
-	case 8:
-		commandLine.createGrafanaDashboard()
-	default:
-		commandLine.logger.Fatal("Couldn't find the application choice", zap.Int("Choice", choice))
-	}
-}
-
-// downloadTogglTimeAsCsv downloads and stores the Toggl Time entries in a CSV file.
-func (commandLine *CommandLine) downloadTogglTimeAsCsv(args []string) {
-	fmt.Println("Execute: Download Toggl Time as CSV file.")
-	if len(args) < 2 {
-		commandLine.logger.Fatal("Provide the year, and the month as arguments")
-	}
-	year, err := strconv.Atoi(args[0])
-	if err != nil {
-		commandLine.logger.Fatal("Error converting the year argument to numeric value", zap.Error(err))
-	}
-	month, err := strconv.Atoi(args[1])
-	if err != nil {
-		commandLine.logger.Fatal("Error converting the month argument to numeric value", zap.Error(err))
-	}
-	togglClient := toggl.NewTogglClient(commandLine.config, commandLine.logger)
-	togglTime, err := toggl.NewTogglTime(commandLine.logger, togglClient)
-	if err != nil {
-		commandLine.logger.Fatal("Error creating TogglTime", zap.Error(err))
-	}
-
-	startTime := time.Date(year, time.Month(month), 01, 0, 0, 0, 0, time.UTC)
-	endTime := startTime.AddDate(0, 1, -1)
-
-	err = togglTime.DownloadAsCsv(startTime, endTime)
-	if err != nil {
-		commandLine.logger.Fatal("Error retrieving and storing the time range from Toggl", zap.Error(err))
-	}
-}
-
-// downloadTrelloCardsAsCsv downloads and stores the Trello Card entries in a CSV file.
-func (commandLine *CommandLine) downloadTrelloCardsAsCsv() {
-	fmt.Println("Execute: Download Trello cards as CSV file.")
-	client := trelloLib.NewClient(commandLine.config.TrelloConfiguration.AppKey, commandLine.config.TrelloConfiguration.ApiToken)
-	trelloClient := trello.NewTrelloClient(commandLine.config, commandLine.logger, client)
-	trello, err := trello.NewTrello(commandLine.logger, trelloClient)
-	if err != nil {
-		commandLine.logger.Fatal("Error creating Trello", zap.Error(err))
+// Execute runs the subcommand selected by the command line arguments, e.g. "toggl export -month=2024-05".
+func (commandLine *CommandLine) Execute(arguments []string) error {
+	if len(arguments) > 0 && isDeprecatedChoice(arguments[0]) {
+		translatedArguments, err := translateDeprecatedChoice(arguments)
+		if err != nil {
+			fmt.Fprintf(commandLine.output, "%s\n", err.Error())
+			commandLine.printUsage()
+			return err
+		}
+		fmt.Fprintf(commandLine.output, "Warning: the -choice flag is deprecated, use \"%s %s\" instead.\n", applicationName, strings.Join(translatedArguments, " "))
+		arguments = translatedArguments
 	}
-	err = trello.DownloadAsCsv()
-	if err != nil {
-		commandLine.logger.Fatal("Error retrieving and storing the cards from Trello", zap.Error(err))
+	if len(arguments) == 0 {
+		commandLine.printUsage()
+		return &UsageError{Message: "missing command"}
 	}
-}
-
-// insertFromCsv inserts the database entries for either the Toggl Time or the Trello Cards from a CSV file.
-func (commandLine *CommandLine) insertFromCsv(args []string) {
-	fmt.Println("Execute: Insert from CSV file.")
-	if len(args) < 2 {
-		commandLine.logger.Fatal("Provide the file name, and the database table name as arguments")
+	switch arguments[0] {
+	case "help", "-h", "-help", "--help":
+		commandLine.printUsage()
+		return nil
 	}
-	fileName := args[0]
-	databaseTableName := args[1]
-	if databaseTableName != "toggl_time" && databaseTableName != "trello_card" {
-		commandLine.logger.Fatal("Provide the correct database table name as argument. Choose from 'toggl_time' and 'trello_card'.")
+	if len(arguments) < 2 {
+		commandLine.printUsage()
+		return &UsageError{Command: arguments[0], Message: "missing command"}
 	}
-	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
-	defer func() {
-		dberr := postgresqlConnection.Close()
-		if dberr != nil {
-			commandLine.logger.Fatal("Error closing the PostgreSQL connection", zap.Error(dberr))
+	for _, command := range commandLine.commands {
+		if command.group == arguments[0] && command.name == arguments[1] {
+			return commandLine.executeCommand(command, arguments[2:])
 		}
-	}()
-	insertFromCsv, err := storage.NewInsertFromCsv(commandLine.logger, postgresqlConnection.GetDb())
-	if err != nil {
-		commandLine.logger.Fatal("Error creating InsertFromCsv", zap.Error(err))
-	}
-	err = executeInsertFromCsv(insertFromCsv, fileName, databaseTableName)
-	if err != nil {
-		commandLine.logger.Fatal("Error inserting the CSV file entries into the database", zap.String("Databse table name", databaseTableName), zap.Error(err))
 	}
+	err := &UsageError{Command: arguments[0] + " " + arguments[1], Message: "unknown command"}
+	fmt.Fprintf(commandLine.output, "%s\n", err.Error())
+	commandLine.printUsage()
+	return err
 }
 
-func executeInsertFromCsv(insertFromCsv *storage.InsertFromCsv, fileName string, databaseTableName string) error {
-	switch databaseTableName {
-	case "toggl_time":
-		return insertFromCsv.Insert(fileName, databaseTableName, toggl.TogglTimeEntry{})
-	case "trello_card":
-		return insertFromCsv.Insert(fileName, databaseTableName, trello.TrelloCardEntry{})
+// printUsage prints the list of the available commands.
+func (commandLine *CommandLine) printUsage() {
+	fmt.Fprintf(commandLine.output, "Usage: %s <group> <command> [flags]\n\nCommands:\n", applicationName)
+	for _, command := range commandLine.commands {
+		fmt.Fprintf(commandLine.output, "  %-20s %s\n", command.path(), command.description)
 	}
-	return nil
+	fmt.Fprintf(commandLine.output, "\nRun \"%s <group> <command> -help\" for the command flags.\n", applicationName)
+	fmt.Fprintf(commandLine.output, "The numeric -choice flag is deprecated, but still supported.\n")
 }
 
-// storeTogglTime downloads and stores the Toggl Time entries in the database.
-func (commandLine *CommandLine) storeTogglTime() {
-	fmt.Println("Execute: Store Toggl Time.")
-	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
-	defer func() {
-		dberr := postgresqlConnection.Close()
-		if dberr != nil {
-			commandLine.logger.Fatal("Error closing the PostgreSQL connection", zap.Error(dberr))
-		}
-	}()
-	togglClient := toggl.NewTogglClient(commandLine.config, commandLine.logger)
-	togglTime, err := toggl.NewTogglTimeWithDatabaseConnection(commandLine.logger, togglClient, postgresqlConnection.GetDb())
-	if err != nil {
-		commandLine.logger.Fatal("Error creating TogglTime", zap.Error(err))
-	}
-
-	startTime := time.Date(2021, 02, 01, 01, 00, 00, 0, time.UTC)
-	endTime := time.Date(2021, 02, 06, 23, 59, 59, 999999999, time.UTC)
-
-	err = togglTime.Store(startTime, endTime)
-	if err != nil {
-		commandLine.logger.Fatal("Error retrieving and storing the time range from Toggl", zap.Error(err))
-	}
+func isDeprecatedChoice(argument string) bool {
+	return strings.HasPrefix(argument, "-choice") || strings.HasPrefix(argument, "--choice")
 }
 
-// storeTrelloBoard downloads and stores the Trello Card entries in the database.
-func (commandLine *CommandLine) storeTrelloBoard() {
-	fmt.Println("Execute: Store Trello Board.")
-	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
-	defer func() {
-		dberr := postgresqlConnection.Close()
-		if dberr != nil {
-			commandLine.logger.Fatal("Error closing the PostgreSQL connection", zap.Error(dberr))
-		}
-	}()
-	client := trelloLib.NewClient(commandLine.config.TrelloConfiguration.AppKey, commandLine.config.TrelloConfiguration.ApiToken)
-	trelloClient := trello.NewTrelloClient(commandLine.config, commandLine.logger, client)
-	trello, err := trello.NewTrelloWithDatabaseConnection(commandLine.logger, trelloClient, postgresqlConnection.GetDb())
+// translateDeprecatedChoice converts the "-choice=N [arguments]" command line into the corresponding subcommand arguments.
+func translateDeprecatedChoice(arguments []string) ([]string, error) {
+	flagSet := flag.NewFlagSet("choice", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	choiceValue := flagSet.Int("choice", 1, "Application execution choice")
+	err := flagSet.Parse(arguments)
 	if err != nil {
-		commandLine.logger.Fatal("Error creating Trello", zap.Error(err))
+		return nil, &UsageError{Message: err.Error()}
 	}
-	err = trello.Store()
-	if err != nil {
-		commandLine.logger.Fatal("Error retrieving and storing the cards from Trello", zap.Error(err))
+	choice, found := deprecatedChoices[*choiceValue]
+	if !found {
+		return nil, &UsageError{Message: fmt.Sprintf("couldn't find the application choice %d", *choiceValue)}
 	}
-}
-
-// downloadTableAsCsv downloads either the Toggl Time or the Trello Cards from the database to a CSV file.
-func (commandLine *CommandLine) downloadTableAsCsv(args []string) {
-	fmt.Println("Execute: Download table as CSV.")
-	if len(args) == 0 {
-		commandLine.logger.Fatal("Provide the database table name as argument")
+	translatedArguments := []string{choice.group, choice.name}
+	if choice.translate != nil {
+		return append(translatedArguments, choice.translate(flagSet.Args())...), nil
 	}
-	databaseTableName := args[0]
-	if databaseTableName != "toggl_time" && databaseTableName != "trello_card" {
-		commandLine.logger.Fatal("Provide the correct database table name as argument. Choose from 'toggl_time' and 'trello_card'.")
-	}
-	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
-	defer func() {
-		dberr := postgresqlConnection.Close()
-		if dberr != nil {
-			commandLine.logger.Fatal("Error closing the PostgreSQL connection", zap.Error(dberr))
-		}
-	}()
-	downloadAsCsv, err := storage.NewDownloadAsCsv(commandLine.logger, postgresqlConnection.GetDb())
-	if err != nil {
-		commandLine.logger.Fatal("Error creating NewDownloadAsCsv", zap.Error(err))
-	}
-	if len(args) == 1 {
-		err := downloadAsCsv.DownloadAll(databaseTableName)
-		if err != nil {
-			commandLine.logger.Fatal("Cannot download the database table as CSV", zap.String("Databse table name", databaseTableName), zap.Error(err))
-		}
-	} else {
-		columnsFilter := strings.Split(args[1], ",")
-		err := downloadAsCsv.Download(databaseTableName, columnsFilter)
-		if err != nil {
-			commandLine.logger.Fatal("Cannot download the database table as CSV", zap.String("Databse table name", databaseTableName), zap.Error(err))
+	for i, argument := range flagSet.Args() {
+		if i < len(choice.positionalFlags) {
+			translatedArguments = append(translatedArguments, fmt.Sprintf("-%s=%s", choice.positionalFlags[i], argument))
+		} else {
+			translatedArguments = append(translatedArguments, argument)
 		}
 	}
+	return translatedArguments, nil
 }
 
-// updateFromCsv updates the database entries for the specified table from a CSV file.
-func (commandLine *CommandLine) updateFromCsv(args []string) {
-	fmt.Println("Execute: Update table from CSV.")
-	if len(args) < 3 {
-		commandLine.logger.Fatal("Provide the file name, the database table name, and the column name as arguments")
-	}
-	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
-	defer func() {
-		dberr := postgresqlConnection.Close()
-		if dberr != nil {
-			commandLine.logger.Fatal("Error closing the PostgreSQL connection", zap.Error(dberr))
-		}
-	}()
-	fileName := args[0]
-	updateFromCsv := storage.NewUpdateFromCsv(commandLine.logger, postgresqlConnection.GetDb())
-	databaseTableName := args[1]
-	columnName := args[2]
-	err := updateFromCsv.Upload(fileName, databaseTableName, columnName)
-	if err != nil {
-		commandLine.logger.Fatal("Cannot update the database table from CSV", zap.String("File name", fileName), zap.String("Database table name", databaseTableName), zap.Error(err))
+// translateYearAndMonth converts the "year month" positional arguments into the -month flag.
+func translateYearAndMonth(arguments []string) []string {
+	if len(arguments) < 2 {
+		return arguments
 	}
+	return append([]string{fmt.Sprintf("-month=%s-%s", arguments[0], arguments[1])}, arguments[2:]...)
 }
 
 func initPostgresqlConnection(config configuration.Configuration, logger *zap.Logger) (postgresqlConnection storage.PostgresqlConnection) {
@@ -250,22 +164,26 @@ func initPostgresqlConnection(config configuration.Configuration, logger *zap.Lo
 	if err != nil {
 		logger.Fatal("Couldn't connect to the database", zap.Error(err))
 	}
-	err = postgresqlConnection.InitDatabase()
+	err = postgresqlConnection.InitDatabase(config.DBConfiguration.AutoMigrate)
 	if err != nil {
 		logger.Fatal("Couldn't initialize the database", zap.Error(err))
 	}
 	return
 }
 
-// createGrafanaDashboard creates the Grafana json definition from the application configuration.
-func (commandLine *CommandLine) createGrafanaDashboard() {
-	fmt.Println("Execute: Create the Grafana Dashboard.")
-	grafanaDashboard, err := grafana.NewGrafanaDashboard(commandLine.config, commandLine.logger)
+// newHttpClient creates the HTTP client for the HTTP mode in the configuration, either live, record or replay.
+func (commandLine *CommandLine) newHttpClient() *http.Client {
+	httpClient, err := httpclient.NewHttpClient(commandLine.config.ApplicationConfiguration)
 	if err != nil {
-		commandLine.logger.Fatal("Error creating GrafanaDashboard", zap.Error(err))
+		commandLine.logger.Fatal("Error creating the HTTP client", zap.Error(err))
 	}
-	err = grafanaDashboard.CreateDashboard()
-	if err != nil {
-		commandLine.logger.Fatal("Cannot create the Grafana Dashboard", zap.Error(err))
+	return httpClient
+}
+
+// closePostgresqlConnection closes the PostgreSQL connection opened by initPostgresqlConnection.
+func (commandLine *CommandLine) closePostgresqlConnection(postgresqlConnection storage.PostgresqlConnection) {
+	dberr := postgresqlConnection.Close()
+	if dberr != nil {
+		commandLine.logger.Fatal("Error closing the PostgreSQL connection", zap.Error(dberr))
 	}
 }
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"go.uber.org/zap"
+)
+
+func TestTranslateDeprecatedChoice(t *testing.T) {
+	arguments, err := translateDeprecatedChoice([]string{"-choice=1", "2021", "02"})
+	if err != nil {
+		t.Fatalf("Error translating the deprecated choice: %v", err)
+	}
+	assert.Equal(t, []string{"toggl", "export", "-month=2021-02"}, arguments, "Expected the toggl export command")
+}
+
+func TestTranslateDeprecatedChoiceWithSeparateValue(t *testing.T) {
+	arguments, err := translateDeprecatedChoice([]string{"-choice", "7", "trello_entries.csv", "trello_card", "customer"})
+	if err != nil {
+		t.Fatalf("Error translating the deprecated choice: %v", err)
+	}
+	assert.Equal(t, []string{"db", "update", "-file=trello_entries.csv", "-table=trello_card", "-column=customer"}, arguments, "Expected the db update command")
+}
+
+func TestTranslateDeprecatedChoiceThrowsUsageErrorOnUnknownChoice(t *testing.T) {
+	_, err := translateDeprecatedChoice([]string{"-choice=9"})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnMissingCommand(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnUnknownCommand(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{"toggl", "unknown"})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnMissingFlag(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{"db", "import", "-table=toggl_time"})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnInvalidTableName(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{"db", "export", "-table=unknown"})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnUnexpectedArguments(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{"grafana", "dashboard", "unexpected"})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnInvalidMigrationSteps(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{"migrate", "down", "-steps=0"})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnIncrementalSyncWithDateRange(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{"toggl", "sync", "-incremental", "-month=2021-02"})
+	verifyUsageError(t, err)
+}
+
+func TestExecuteThrowsUsageErrorOnDeprecatedChoiceWithInvalidArguments(t *testing.T) {
+	commandLine := newTestCommandLine()
+
+	err := commandLine.Execute([]string{"-choice=1", "2021", "13"})
+	verifyUsageError(t, err)
+}
+
+func verifyUsageError(t *testing.T, err error) {
+	if err == nil {
+		t.Fatalf("Expect a UsageError.")
+	}
+	switch err.(type) {
+	case *UsageError:
+		return
+	default:
+		t.Errorf("Expect a UsageError, got %v.", err)
+	}
+}
+
+func newTestCommandLine() CommandLine {
+	commandLine := NewCommandLine(configuration.Configuration{}, zap.NewNop())
+	commandLine.output = &bytes.Buffer{}
+	return commandLine
+}
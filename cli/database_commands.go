@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"github.com/sitMCella/toggl-trello-kpi/toggl"
+	"github.com/sitMCella/toggl-trello-kpi/trello"
+	"go.uber.org/zap"
+)
+
+// databaseImportCommand defines the "db import" command.
+func (commandLine *CommandLine) databaseImportCommand(flagSet *flag.FlagSet) func() error {
+	fileName := flagSet.String("file", "", "CSV file name")
+	databaseTableName := flagSet.String("table", "", "database table name, either 'toggl_time' or 'trello_card'")
+	return func() error {
+		if err := requireFlag("file", *fileName); err != nil {
+			return err
+		}
+		if err := validateTableName(*databaseTableName); err != nil {
+			return err
+		}
+		commandLine.insertFromCsv(*fileName, *databaseTableName)
+		return nil
+	}
+}
+
+// databaseExportCommand defines the "db export" command.
+func (commandLine *CommandLine) databaseExportCommand(flagSet *flag.FlagSet) func() error {
+	databaseTableName := flagSet.String("table", "", "database table name, either 'toggl_time' or 'trello_card'")
+	columns := flagSet.String("columns", "", "comma separated list of the columns to download, all the columns if empty")
+	return func() error {
+		if err := validateTableName(*databaseTableName); err != nil {
+			return err
+		}
+		var columnsFilter []string
+		if *columns != "" {
+			columnsFilter = strings.Split(*columns, ",")
+		}
+		commandLine.downloadTableAsCsv(*databaseTableName, columnsFilter)
+		return nil
+	}
+}
+
+// databaseUpdateCommand defines the "db update" command.
+func (commandLine *CommandLine) databaseUpdateCommand(flagSet *flag.FlagSet) func() error {
+	fileName := flagSet.String("file", "", "CSV file name")
+	databaseTableName := flagSet.String("table", "", "database table name")
+	columnName := flagSet.String("column", "", "name of the column to update")
+	return func() error {
+		if err := requireFlag("file", *fileName); err != nil {
+			return err
+		}
+		if err := requireFlag("table", *databaseTableName); err != nil {
+			return err
+		}
+		if err := requireFlag("column", *columnName); err != nil {
+			return err
+		}
+		commandLine.updateFromCsv(*fileName, *databaseTableName, *columnName)
+		return nil
+	}
+}
+
+// insertFromCsv inserts the database entries for either the Toggl Time or the Trello Cards from a CSV file.
+func (commandLine *CommandLine) insertFromCsv(fileName string, databaseTableName string) {
+	fmt.Println("Execute: Insert from CSV file.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	insertFromCsv, err := storage.NewInsertFromCsv(commandLine.logger, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating InsertFromCsv", zap.Error(err))
+	}
+	err = executeInsertFromCsv(insertFromCsv, fileName, databaseTableName)
+	if err != nil {
+		commandLine.logger.Fatal("Error inserting the CSV file entries into the database", zap.String("Databse table name", databaseTableName), zap.Error(err))
+	}
+}
+
+func executeInsertFromCsv(insertFromCsv *storage.InsertFromCsv, fileName string, databaseTableName string) error {
+	switch databaseTableName {
+	case "toggl_time":
+		return insertFromCsv.Insert(fileName, databaseTableName, toggl.TogglTimeEntry{})
+	case "trello_card":
+		return insertFromCsv.Insert(fileName, databaseTableName, trello.TrelloCardEntry{})
+	}
+	return nil
+}
+
+// downloadTableAsCsv downloads either the Toggl Time or the Trello Cards from the database to a CSV file.
+func (commandLine *CommandLine) downloadTableAsCsv(databaseTableName string, columnsFilter []string) {
+	fmt.Println("Execute: Download table as CSV.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	downloadAsCsv, err := storage.NewDownloadAsCsv(commandLine.logger, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating NewDownloadAsCsv", zap.Error(err))
+	}
+	if len(columnsFilter) == 0 {
+		err := downloadAsCsv.DownloadAll(databaseTableName)
+		if err != nil {
+			commandLine.logger.Fatal("Cannot download the database table as CSV", zap.String("Databse table name", databaseTableName), zap.Error(err))
+		}
+	} else {
+		err := downloadAsCsv.Download(databaseTableName, columnsFilter)
+		if err != nil {
+			commandLine.logger.Fatal("Cannot download the database table as CSV", zap.String("Databse table name", databaseTableName), zap.Error(err))
+		}
+	}
+}
+
+// updateFromCsv updates the database entries for the specified table from a CSV file.
+func (commandLine *CommandLine) updateFromCsv(fileName string, databaseTableName string, columnName string) {
+	fmt.Println("Execute: Update table from CSV.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	updateFromCsv := storage.NewUpdateFromCsv(commandLine.logger, postgresqlConnection.GetDb())
+	err := updateFromCsv.Upload(fileName, databaseTableName, columnName)
+	if err != nil {
+		commandLine.logger.Fatal("Cannot update the database table from CSV", zap.String("File name", fileName), zap.String("Database table name", databaseTableName), zap.Error(err))
+	}
+}
+
+// printSyncReport prints the number of rows inserted, updated or unchanged by a sync.
+func printSyncReport(report storage.SyncReport) {
+	fmt.Printf("Inserted: %d, updated: %d, unchanged: %d.\n", report.Inserted, report.Updated, report.Unchanged)
+}
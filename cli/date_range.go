@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"flag"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+const monthLayout = "2006-1"
+
+// dateRange struct defines the date range flags shared by the Toggl commands.
+type dateRange struct {
+	from          *string
+	to            *string
+	month         *string
+	lastWeek      *bool
+	sinceLastSync *bool
+}
+
+// newDateRange registers the date range flags in the command flag set.
+func newDateRange(flagSet *flag.FlagSet) *dateRange {
+	return &dateRange{
+		from:          flagSet.String("from", "", "first day of the range, e.g. 2024-05-01"),
+		to:            flagSet.String("to", "", "last day of the range, e.g. 2024-05-31 (default today)"),
+		month:         flagSet.String("month", "", "whole month range, e.g. 2024-05"),
+		lastWeek:      flagSet.Bool("last-week", false, "range from Monday to Sunday of the previous week"),
+		sinceLastSync: flagSet.Bool("since-last-sync", false, "range from the last time entry stored in the database until now"),
+	}
+}
+
+// isSet returns true when at least one of the date range flags is set.
+func (dateRange *dateRange) isSet() bool {
+	return *dateRange.from != "" || *dateRange.to != "" || *dateRange.month != "" || *dateRange.lastWeek || *dateRange.sinceLastSync
+}
+
+// resolve converts the date range flags into the start and end time of the range.
+// The lastSync function is called only for the -since-last-sync flag and returns the zero time when no previous sync exists.
+func (dateRange *dateRange) resolve(now time.Time, lastSync func() (time.Time, error)) (startTime time.Time, endTime time.Time, err error) {
+	now = now.UTC()
+	selected := 0
+	for _, isSet := range []bool{*dateRange.from != "", *dateRange.month != "", *dateRange.lastWeek, *dateRange.sinceLastSync} {
+		if isSet {
+			selected++
+		}
+	}
+	if selected == 0 {
+		err = newUsageError("provide the date range with one of the -from, -month, -last-week or -since-last-sync flags")
+		return
+	}
+	if selected > 1 {
+		err = newUsageError("the -from, -month, -last-week and -since-last-sync flags are mutually exclusive")
+		return
+	}
+	if *dateRange.to != "" && (*dateRange.month != "" || *dateRange.lastWeek) {
+		err = newUsageError("the -to flag can be used only with the -from or -since-last-sync flags")
+		return
+	}
+	endTime = endOfDay(now)
+	if *dateRange.to != "" {
+		var to time.Time
+		to, err = time.Parse(dateLayout, *dateRange.to)
+		if err != nil {
+			err = newUsageError("invalid -to date %q, use the format YYYY-MM-DD", *dateRange.to)
+			return
+		}
+		endTime = endOfDay(to)
+	}
+	switch {
+	case *dateRange.from != "":
+		startTime, err = time.Parse(dateLayout, *dateRange.from)
+		if err != nil {
+			err = newUsageError("invalid -from date %q, use the format YYYY-MM-DD", *dateRange.from)
+			return
+		}
+	case *dateRange.month != "":
+		startTime, err = time.Parse(monthLayout, *dateRange.month)
+		if err != nil {
+			err = newUsageError("invalid -month %q, use the format YYYY-MM", *dateRange.month)
+			return
+		}
+		endTime = endOfDay(startTime.AddDate(0, 1, -1))
+	case *dateRange.lastWeek:
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		startTime = startOfDay(now).AddDate(0, 0, -daysSinceMonday-7)
+		endTime = endOfDay(startTime.AddDate(0, 0, 6))
+	case *dateRange.sinceLastSync:
+		var lastSyncTime time.Time
+		lastSyncTime, err = lastSync()
+		if err != nil {
+			return
+		}
+		if lastSyncTime.IsZero() {
+			err = newUsageError("no previous sync found in the database, use the -from or -month flags")
+			return
+		}
+		startTime = lastSyncTime.UTC()
+	}
+	if endTime.Before(startTime) {
+		err = newUsageError("the end of the date range is before the start")
+	}
+	return
+}
+
+func startOfDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func endOfDay(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 999999999, time.UTC)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+)
+
+var now = time.Date(2024, time.Month(05), 15, 10, 30, 0, 0, time.UTC)
+
+func TestDateRangeFromTo(t *testing.T) {
+	startTime, endTime, err := resolveDateRange([]string{"-from=2024-04-03", "-to=2024-04-10"}, nil)
+	if err != nil {
+		t.Fatalf("Error resolving the date range: %v", err)
+	}
+	assert.Equal(t, time.Date(2024, time.Month(04), 03, 0, 0, 0, 0, time.UTC), startTime, "Expected the start of the from day")
+	assert.Equal(t, time.Date(2024, time.Month(04), 10, 23, 59, 59, 999999999, time.UTC), endTime, "Expected the end of the to day")
+}
+
+func TestDateRangeFromUntilToday(t *testing.T) {
+	_, endTime, err := resolveDateRange([]string{"-from=2024-04-03"}, nil)
+	if err != nil {
+		t.Fatalf("Error resolving the date range: %v", err)
+	}
+	assert.Equal(t, time.Date(2024, time.Month(05), 15, 23, 59, 59, 999999999, time.UTC), endTime, "Expected the end of today")
+}
+
+func TestDateRangeMonth(t *testing.T) {
+	startTime, endTime, err := resolveDateRange([]string{"-month=2024-02"}, nil)
+	if err != nil {
+		t.Fatalf("Error resolving the date range: %v", err)
+	}
+	assert.Equal(t, time.Date(2024, time.Month(02), 01, 0, 0, 0, 0, time.UTC), startTime, "Expected the first day of the month")
+	assert.Equal(t, time.Date(2024, time.Month(02), 29, 23, 59, 59, 999999999, time.UTC), endTime, "Expected the last day of the month")
+}
+
+func TestDateRangeLastWeek(t *testing.T) {
+	startTime, endTime, err := resolveDateRange([]string{"-last-week"}, nil)
+	if err != nil {
+		t.Fatalf("Error resolving the date range: %v", err)
+	}
+	assert.Equal(t, time.Date(2024, time.Month(05), 06, 0, 0, 0, 0, time.UTC), startTime, "Expected the Monday of the previous week")
+	assert.Equal(t, time.Date(2024, time.Month(05), 12, 23, 59, 59, 999999999, time.UTC), endTime, "Expected the Sunday of the previous week")
+}
+
+func TestDateRangeSinceLastSync(t *testing.T) {
+	lastSync := time.Date(2024, time.Month(05), 10, 18, 0, 1, 0, time.UTC)
+	startTime, endTime, err := resolveDateRange([]string{"-since-last-sync"}, func() (time.Time, error) { return lastSync, nil })
+	if err != nil {
+		t.Fatalf("Error resolving the date range: %v", err)
+	}
+	assert.Equal(t, lastSync, startTime, "Expected the last sync time")
+	assert.Equal(t, time.Date(2024, time.Month(05), 15, 23, 59, 59, 999999999, time.UTC), endTime, "Expected the end of today")
+}
+
+func TestDateRangeSinceLastSyncThrowsUsageErrorWithoutPreviousSync(t *testing.T) {
+	_, _, err := resolveDateRange([]string{"-since-last-sync"}, func() (time.Time, error) { return time.Time{}, nil })
+	verifyUsageError(t, err)
+}
+
+func TestDateRangeSinceLastSyncThrowsLastSyncError(t *testing.T) {
+	lastSyncError := errors.New("database error")
+	_, _, err := resolveDateRange([]string{"-since-last-sync"}, func() (time.Time, error) { return time.Time{}, lastSyncError })
+	assert.Equal(t, lastSyncError, err, "Expected the last sync error")
+}
+
+func TestDateRangeThrowsUsageErrorOnMissingRange(t *testing.T) {
+	_, _, err := resolveDateRange([]string{}, nil)
+	verifyUsageError(t, err)
+}
+
+func TestDateRangeThrowsUsageErrorOnMultipleRanges(t *testing.T) {
+	_, _, err := resolveDateRange([]string{"-month=2024-05", "-last-week"}, nil)
+	verifyUsageError(t, err)
+}
+
+func TestDateRangeThrowsUsageErrorOnInvalidDate(t *testing.T) {
+	_, _, err := resolveDateRange([]string{"-from=2024/05/01"}, nil)
+	verifyUsageError(t, err)
+}
+
+func TestDateRangeThrowsUsageErrorOnEndBeforeStart(t *testing.T) {
+	_, _, err := resolveDateRange([]string{"-from=2024-05-10", "-to=2024-05-01"}, nil)
+	verifyUsageError(t, err)
+}
+
+func resolveDateRange(arguments []string, lastSync func() (time.Time, error)) (time.Time, time.Time, error) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	dateRange := newDateRange(flagSet)
+	err := flagSet.Parse(arguments)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return dateRange.resolve(now, lastSync)
+}
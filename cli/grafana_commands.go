@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sitMCella/toggl-trello-kpi/grafana"
+	"go.uber.org/zap"
+)
+
+// grafanaDashboardCommand defines the "grafana dashboard" command.
+func (commandLine *CommandLine) grafanaDashboardCommand(flagSet *flag.FlagSet) func() error {
+	return func() error {
+		commandLine.createGrafanaDashboard()
+		return nil
+	}
+}
+
+// createGrafanaDashboard creates the Grafana json definition from the application configuration.
+func (commandLine *CommandLine) createGrafanaDashboard() {
+	fmt.Println("Execute: Create the Grafana Dashboard.")
+	grafanaDashboard, err := grafana.NewGrafanaDashboard(commandLine.config, commandLine.logger)
+	if err != nil {
+		commandLine.logger.Fatal("Error creating GrafanaDashboard", zap.Error(err))
+	}
+	err = grafanaDashboard.CreateDashboard()
+	if err != nil {
+		commandLine.logger.Fatal("Cannot create the Grafana Dashboard", zap.Error(err))
+	}
+}
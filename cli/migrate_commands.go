@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// migrateUpCommand defines the "migrate up" command.
+func (commandLine *CommandLine) migrateUpCommand(flagSet *flag.FlagSet) func() error {
+	return func() error {
+		commandLine.migrateUp()
+		return nil
+	}
+}
+
+// migrateDownCommand defines the "migrate down" command.
+func (commandLine *CommandLine) migrateDownCommand(flagSet *flag.FlagSet) func() error {
+	steps := flagSet.Int("steps", 1, "number of migrations to revert")
+	return func() error {
+		if *steps < 1 {
+			return newUsageError("the -steps flag must be greater than zero")
+		}
+		commandLine.migrateDown(*steps)
+		return nil
+	}
+}
+
+// migrateStatusCommand defines the "migrate status" command.
+func (commandLine *CommandLine) migrateStatusCommand(flagSet *flag.FlagSet) func() error {
+	return func() error {
+		commandLine.printMigrationStatus()
+		return nil
+	}
+}
+
+// migrateUp applies the pending database migrations.
+func (commandLine *CommandLine) migrateUp() {
+	fmt.Println("Execute: Apply the database migrations.")
+	postgresqlConnection, migrator := commandLine.initMigrator()
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	applied, err := migrator.Up()
+	for _, migration := range applied {
+		fmt.Printf("Applied: %03d_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		commandLine.logger.Fatal("Cannot apply the database migrations", zap.Error(err))
+	}
+	fmt.Printf("Applied %d migrations.\n", len(applied))
+}
+
+// migrateDown reverts the last applied database migrations.
+func (commandLine *CommandLine) migrateDown(steps int) {
+	fmt.Println("Execute: Revert the database migrations.")
+	postgresqlConnection, migrator := commandLine.initMigrator()
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	reverted, err := migrator.Down(steps)
+	for _, migration := range reverted {
+		fmt.Printf("Reverted: %03d_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		commandLine.logger.Fatal("Cannot revert the database migrations", zap.Error(err))
+	}
+	fmt.Printf("Reverted %d migrations.\n", len(reverted))
+}
+
+// printMigrationStatus prints the state of the database migrations.
+func (commandLine *CommandLine) printMigrationStatus() {
+	postgresqlConnection, migrator := commandLine.initMigrator()
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	statuses, err := migrator.Status()
+	if err != nil {
+		commandLine.logger.Fatal("Cannot read the database migrations", zap.Error(err))
+	}
+	for _, status := range statuses {
+		state := "pending"
+		if status.Applied {
+			state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if status.Modified {
+			state += " (modified after it was applied)"
+		}
+		fmt.Printf("%03d_%-40s %s\n", status.Version, status.Name, state)
+	}
+}
+
+// initMigrator opens the PostgreSQL connection without applying the migrations.
+func (commandLine *CommandLine) initMigrator() (storage.PostgresqlConnection, *storage.Migrator) {
+	postgresqlConnection, err := storage.NewPostgresConnection(commandLine.config.DBConfiguration)
+	if err != nil {
+		commandLine.logger.Fatal("Couldn't connect to the database", zap.Error(err))
+	}
+	migrator, err := storage.NewMigrator(postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Migrator", zap.Error(err))
+	}
+	return postgresqlConnection, migrator
+}
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
+	"github.com/sitMCella/toggl-trello-kpi/linking"
+	"github.com/sitMCella/toggl-trello-kpi/toggl"
+	"go.uber.org/zap"
+)
+
+// togglExportCommand defines the "toggl export" command.
+func (commandLine *CommandLine) togglExportCommand(flagSet *flag.FlagSet) func() error {
+	dateRange := newDateRange(flagSet)
+	reportFile := newReportFileFlag(flagSet)
+	return func() error {
+		startTime, endTime, err := commandLine.resolveTogglDateRange(dateRange, *reportFile)
+		if err != nil {
+			return err
+		}
+		commandLine.downloadTogglTimeAsCsv(startTime, endTime, *reportFile)
+		return nil
+	}
+}
+
+// togglSyncCommand defines the "toggl sync" command.
+func (commandLine *CommandLine) togglSyncCommand(flagSet *flag.FlagSet) func() error {
+	dateRange := newDateRange(flagSet)
+	preserve := flagSet.String("preserve", "trello_card_id", "comma separated list of the toggl_time columns that keep the stored value when a time entry is updated")
+	reportFile := newReportFileFlag(flagSet)
+	incremental := flagSet.Bool("incremental", false, "retrieve only the time entries modified since the last sync of each workspace")
+	return func() error {
+		if *incremental {
+			if dateRange.isSet() || *reportFile != "" {
+				return newUsageError("the -incremental flag can't be used with the date range flags or the -report-file flag")
+			}
+			if commandLine.config.TogglConfiguration.ApiVersion != "v9" {
+				return newUsageError("the -incremental flag requires the Toggl API v9, found %q", commandLine.config.TogglConfiguration.ApiVersion)
+			}
+			commandLine.storeTogglTimeIncremental(splitList(*preserve))
+			return nil
+		}
+		startTime, endTime, err := commandLine.resolveTogglDateRange(dateRange, *reportFile)
+		if err != nil {
+			return err
+		}
+		commandLine.storeTogglTime(startTime, endTime, splitList(*preserve), *reportFile)
+		return nil
+	}
+}
+
+// togglLinkCommand defines the "toggl link" command.
+func (commandLine *CommandLine) togglLinkCommand(flagSet *flag.FlagSet) func() error {
+	dateRange := newDateRange(flagSet)
+	relink := flagSet.Bool("relink", false, "link again the time entries already linked to a Trello card")
+	return func() error {
+		var startTime, endTime time.Time
+		if dateRange.isSet() {
+			var err error
+			startTime, endTime, err = dateRange.resolve(time.Now(), commandLine.lastTogglEntryStart)
+			if err != nil {
+				return err
+			}
+		}
+		commandLine.linkTogglTime(startTime, endTime, *relink)
+		return nil
+	}
+}
+
+// togglReconcileCommand defines the "toggl reconcile" command.
+func (commandLine *CommandLine) togglReconcileCommand(flagSet *flag.FlagSet) func() error {
+	dateRange := newDateRange(flagSet)
+	mode := flagSet.String("mode", toggl.ReconcileReport, "action on the stored time entries no longer in Toggl, either report, delete or soft-delete")
+	dryRun := flagSet.Bool("dry-run", false, "preview the time entries to delete without changing the database")
+	return func() error {
+		switch *mode {
+		case toggl.ReconcileReport, toggl.ReconcileDelete, toggl.ReconcileSoftDelete:
+		default:
+			return newUsageError("invalid -mode %q, choose from report, delete and soft-delete", *mode)
+		}
+		startTime, endTime, err := dateRange.resolve(time.Now(), commandLine.lastTogglEntryStart)
+		if err != nil {
+			return err
+		}
+		commandLine.reconcileTogglTime(startTime, endTime, *mode, *dryRun)
+		return nil
+	}
+}
+
+// newReportFileFlag defines the flag of the Toggl detailed report export that replaces the Toggl API.
+func newReportFileFlag(flagSet *flag.FlagSet) *string {
+	return flagSet.String("report-file", "", "read the time entries from a Toggl detailed report CSV or JSON export instead of the Toggl API")
+}
+
+// resolveTogglDateRange resolves the date range flags of the Toggl commands.
+// The date range is optional with a Toggl detailed report export, and all the time entries of the export are retrieved when it is not set.
+func (commandLine *CommandLine) resolveTogglDateRange(dateRange *dateRange, reportFile string) (time.Time, time.Time, error) {
+	if reportFile != "" && !dateRange.isSet() {
+		return time.Time{}, time.Time{}, nil
+	}
+	return dateRange.resolve(time.Now(), commandLine.lastTogglEntryStart)
+}
+
+// downloadTogglTimeAsCsv downloads and stores the Toggl Time entries in a CSV file.
+func (commandLine *CommandLine) downloadTogglTimeAsCsv(startTime time.Time, endTime time.Time, reportFile string) {
+	fmt.Println("Execute: Download Toggl Time as CSV file.")
+	togglClient := commandLine.newTogglClient(reportFile)
+	togglTime, err := toggl.NewTogglTime(commandLine.logger, togglClient)
+	if err != nil {
+		commandLine.logger.Fatal("Error creating TogglTime", zap.Error(err))
+	}
+
+	err = togglTime.DownloadAsCsv(startTime, endTime)
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the time range from Toggl", zap.Error(err))
+	}
+}
+
+// storeTogglTime downloads and stores the Toggl Time entries in the database.
+func (commandLine *CommandLine) storeTogglTime(startTime time.Time, endTime time.Time, preservedColumns []string, reportFile string) {
+	fmt.Println("Execute: Store Toggl Time.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	togglClient := commandLine.newTogglClient(reportFile)
+	togglTime, err := toggl.NewTogglTimeWithDatabaseConnection(commandLine.logger, togglClient, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating TogglTime", zap.Error(err))
+	}
+
+	syncedAt := time.Now()
+	if reportFile == "" {
+		referenceClient := commandLine.newTogglReferenceClient()
+		if referenceClient != nil {
+			referenceReport, err := togglTime.StoreReferenceData(referenceClient)
+			if err != nil {
+				commandLine.logger.Fatal("Error retrieving and storing the projects, clients and tags from Toggl", zap.Error(err))
+			}
+			fmt.Print("Projects, clients and tags. ")
+			printSyncReport(referenceReport)
+		}
+	}
+	report, err := togglTime.Store(startTime, endTime, preservedColumns)
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the time range from Toggl", zap.Error(err))
+	}
+	fmt.Print("Time entries. ")
+	printSyncReport(report)
+	if reportFile != "" {
+		return
+	}
+	if commandLine.config.TogglConfiguration.ApiVersion != "v9" {
+		commandLine.logger.Info("The sync time of the Toggl workspaces is recorded only with the Toggl API v9, which supports the incremental sync.", zap.String("api version", commandLine.config.TogglConfiguration.ApiVersion))
+		return
+	}
+	v9Client := toggl.NewTogglV9ClientWithHttpClient(commandLine.config, commandLine.logger, commandLine.newTogglHttpClient())
+	err = togglTime.InitSyncState(v9Client, endTime, syncedAt)
+	if err != nil {
+		commandLine.logger.Fatal("Error storing the sync time of the Toggl workspaces", zap.Error(err))
+	}
+}
+
+// storeTogglTimeIncremental downloads and stores the Toggl Time entries modified since the last sync in the database.
+func (commandLine *CommandLine) storeTogglTimeIncremental(preservedColumns []string) {
+	fmt.Println("Execute: Store Toggl Time incrementally.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	v9Client := toggl.NewTogglV9ClientWithHttpClient(commandLine.config, commandLine.logger, commandLine.newTogglHttpClient())
+	sinceClient, err := toggl.NewRunningEntriesClient(commandLine.logger, v9Client, commandLine.config.TogglConfiguration.RunningEntries)
+	if err != nil {
+		commandLine.logger.Fatal("Error creating the Toggl client", zap.Error(err))
+	}
+	togglTime, err := toggl.NewTogglTimeWithDatabaseConnection(commandLine.logger, sinceClient, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating TogglTime", zap.Error(err))
+	}
+	report, deleted, err := togglTime.StoreIncremental(sinceClient, preservedColumns)
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the modified time entries from Toggl", zap.Error(err))
+	}
+	fmt.Print("Time entries. ")
+	printSyncReport(report)
+	fmt.Printf("Deleted in Toggl: %d.\n", deleted)
+}
+
+// reconcileTogglTime compares the Toggl Time entries stored in the database with the ones in Toggl, and removes the orphan time entries.
+func (commandLine *CommandLine) reconcileTogglTime(startTime time.Time, endTime time.Time, mode string, dryRun bool) {
+	fmt.Println("Execute: Reconcile Toggl Time.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	togglTime, err := toggl.NewTogglTimeWithDatabaseConnection(commandLine.logger, commandLine.newTogglClient(""), postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating TogglTime", zap.Error(err))
+	}
+	summary, err := togglTime.Reconcile(startTime, endTime, commandLine.newTogglReconcileScope(), mode, dryRun)
+	if err != nil {
+		commandLine.logger.Fatal("Error reconciling the time range with Toggl", zap.Error(err))
+	}
+	fmt.Printf("Retrieved: %d, stored: %d, orphans: %d, removed: %d.\n", summary.Retrieved, summary.Stored, summary.Orphans, summary.Removed)
+	if summary.Orphans == 0 {
+		return
+	}
+	fmt.Println("The orphan time entries are listed in the file toggl_orphan_entries.csv.")
+	if mode != toggl.ReconcileReport && dryRun {
+		fmt.Printf("Dry run: run again without the -dry-run flag to %s the orphan time entries.\n", mode)
+	}
+}
+
+// linkTogglTime links the Toggl Time entries stored in the database to the Trello cards.
+func (commandLine *CommandLine) linkTogglTime(startTime time.Time, endTime time.Time, relink bool) {
+	fmt.Println("Execute: Link Toggl Time to Trello cards.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	linker, err := linking.NewLinker(commandLine.config, commandLine.logger, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Linker", zap.Error(err))
+	}
+	summary, err := linker.Link(startTime, endTime, relink)
+	if err != nil {
+		commandLine.logger.Fatal("Error linking the Toggl time entries to the Trello cards", zap.Error(err))
+	}
+	fmt.Printf("Matched: %d (tag: %d, url: %d, short link: %d, name: %d, fuzzy: %d), unmatched: %d, ambiguous: %d.\n",
+		summary.Matched, summary.Methods[linking.CardTag], summary.Methods[linking.CardUrl], summary.Methods[linking.ShortLink],
+		summary.Methods[linking.ExactName], summary.Methods[linking.FuzzyName], summary.Unmatched, summary.Ambiguous)
+	if summary.Unmatched+summary.Ambiguous > 0 {
+		fmt.Println("The unmatched and ambiguous time entries are listed in the file toggl_linking_report.csv.")
+	}
+}
+
+// lastTogglEntryStart retrieves the start time of the last Toggl time entry stored in the database.
+// The time is moved forward by one second, so that the last stored time entry is not retrieved again.
+func (commandLine *CommandLine) lastTogglEntryStart() (time.Time, error) {
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	togglTime, err := toggl.NewTogglTimeWithDatabaseConnection(commandLine.logger, commandLine.newTogglClient(""), postgresqlConnection.GetDb())
+	if err != nil {
+		return time.Time{}, err
+	}
+	lastEntryStart, err := togglTime.LastEntryStart()
+	if err != nil || lastEntryStart.IsZero() {
+		return lastEntryStart, err
+	}
+	return lastEntryStart.Add(time.Second), nil
+}
+
+// newTogglClient creates the Toggl client for the Toggl API version in the configuration.
+func (commandLine *CommandLine) newTogglClient(reportFile string) toggl.Client {
+	if reportFile != "" {
+		return toggl.NewTogglExportClient(commandLine.config, commandLine.logger, reportFile)
+	}
+	togglClient, err := toggl.NewClient(commandLine.config, commandLine.logger, commandLine.newTogglHttpClient())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating the Toggl client", zap.Error(err))
+	}
+	return togglClient
+}
+
+// newTogglReferenceClient creates the client of the Toggl projects, clients and tags for the Toggl API version in the configuration.
+// It returns nil when the Toggl API version doesn't provide the projects, clients and tags by ID.
+func (commandLine *CommandLine) newTogglReferenceClient() toggl.ReferenceClient {
+	switch commandLine.config.TogglConfiguration.ApiVersion {
+	case "v9":
+		return toggl.NewTogglV9ClientWithHttpClient(commandLine.config, commandLine.logger, commandLine.newTogglHttpClient())
+	case "reports":
+		return toggl.NewTogglReportsClientWithHttpClient(commandLine.config, commandLine.logger, commandLine.newTogglHttpClient())
+	default:
+		commandLine.logger.Info("The projects, clients and tags are not synced with the Toggl API version, use either v9 or reports.", zap.String("api version", commandLine.config.TogglConfiguration.ApiVersion))
+		return nil
+	}
+}
+
+// newTogglReconcileScope creates the scope of the stored time entries compared with Toggl for the Toggl API version in the configuration.
+// The Toggl Reports API filters the time entries by the configured workspaces, users, projects and clients, while the Toggl API v8 and v9
+// return all the time entries of the API token owner, so all the stored time entries are compared.
+func (commandLine *CommandLine) newTogglReconcileScope() toggl.ReconcileScope {
+	togglConfiguration := commandLine.config.TogglConfiguration
+	if togglConfiguration.ApiVersion != "reports" {
+		return toggl.ReconcileScope{}
+	}
+	return toggl.ReconcileScope{
+		WorkspaceIds: togglConfiguration.WorkspaceIds,
+		UserIds:      togglConfiguration.UserIds,
+		ProjectIds:   togglConfiguration.ProjectIds,
+		ClientIds:    togglConfiguration.ClientIds,
+	}
+}
+
+// newTogglHttpClient creates the HTTP client with the request rate and retries of the Toggl configuration.
+func (commandLine *CommandLine) newTogglHttpClient() *httpclient.Client {
+	return httpclient.NewClient(commandLine.logger, commandLine.newHttpClient(), commandLine.config.TogglConfiguration.RequestsPerSecond, commandLine.config.TogglConfiguration.MaxRetries)
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	trelloLib "github.com/adlio/trello"
+	"github.com/sitMCella/toggl-trello-kpi/estimation"
+	"github.com/sitMCella/toggl-trello-kpi/trello"
+	"go.uber.org/zap"
+)
+
+// trelloExportCommand defines the "trello export" command.
+func (commandLine *CommandLine) trelloExportCommand(flagSet *flag.FlagSet) func() error {
+	boardFile := newBoardFileFlag(flagSet)
+	return func() error {
+		commandLine.downloadTrelloCardsAsCsv(*boardFile)
+		return nil
+	}
+}
+
+// trelloSyncCommand defines the "trello sync" command.
+func (commandLine *CommandLine) trelloSyncCommand(flagSet *flag.FlagSet) func() error {
+	preserve := flagSet.String("preserve", "", "comma separated list of the trello_card columns that keep the stored value when a card is updated")
+	boardFile := newBoardFileFlag(flagSet)
+	incremental := flagSet.Bool("incremental", false, "retrieve only the boards modified since the last sync")
+	return func() error {
+		if *incremental {
+			if *boardFile != "" {
+				return newUsageError("the -incremental flag can't be used with the -board-file flag")
+			}
+			commandLine.storeTrelloBoardIncremental(splitList(*preserve))
+			return nil
+		}
+		commandLine.storeTrelloBoard(splitList(*preserve), *boardFile)
+		return nil
+	}
+}
+
+// trelloTransitionsCommand defines the "trello transitions" command.
+func (commandLine *CommandLine) trelloTransitionsCommand(flagSet *flag.FlagSet) func() error {
+	boardFile := newBoardFileFlag(flagSet)
+	incremental := flagSet.Bool("incremental", false, "retrieve only the board actions since the last sync")
+	return func() error {
+		if *incremental {
+			if *boardFile != "" {
+				return newUsageError("the -incremental flag can't be used with the -board-file flag")
+			}
+			commandLine.storeTrelloCardTransitionsIncremental()
+			return nil
+		}
+		commandLine.storeTrelloCardTransitions(*boardFile)
+		return nil
+	}
+}
+
+// trelloCycleTimeCommand defines the "trello cycle-time" command.
+func (commandLine *CommandLine) trelloCycleTimeCommand(flagSet *flag.FlagSet) func() error {
+	return func() error {
+		commandLine.downloadTableAsCsv("trello_card_cycle_time", nil)
+		return nil
+	}
+}
+
+// trelloEstimationCommand defines the "trello estimation" command.
+func (commandLine *CommandLine) trelloEstimationCommand(flagSet *flag.FlagSet) func() error {
+	return func() error {
+		commandLine.reportTrelloEstimation()
+		return nil
+	}
+}
+
+// downloadTrelloCardsAsCsv downloads and stores the Trello Card entries in a CSV file.
+func (commandLine *CommandLine) downloadTrelloCardsAsCsv(boardFile string) {
+	fmt.Println("Execute: Download Trello cards as CSV file.")
+	trelloClient := commandLine.newTrelloClient(boardFile)
+	trello, err := trello.NewTrello(commandLine.logger, trelloClient)
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Trello", zap.Error(err))
+	}
+	err = trello.DownloadAsCsv()
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the cards from Trello", zap.Error(err))
+	}
+}
+
+// storeTrelloBoard downloads and stores the Trello Card entries in the database.
+func (commandLine *CommandLine) storeTrelloBoard(preservedColumns []string, boardFile string) {
+	fmt.Println("Execute: Store Trello Board.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	trelloClient := commandLine.newTrelloClient(boardFile)
+	trello, err := trello.NewTrelloWithDatabaseConnection(commandLine.logger, trelloClient, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Trello", zap.Error(err))
+	}
+	labelsReport, err := trello.StoreLabels()
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the labels from Trello", zap.Error(err))
+	}
+	fmt.Print("Labels. ")
+	printSyncReport(labelsReport)
+	report, err := trello.Store(preservedColumns)
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the cards from Trello", zap.Error(err))
+	}
+	fmt.Print("Cards. ")
+	printSyncReport(report)
+}
+
+// storeTrelloCardTransitions downloads and stores the Trello card transition entries in the database.
+func (commandLine *CommandLine) storeTrelloCardTransitions(boardFile string) {
+	fmt.Println("Execute: Store Trello card transitions.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	trelloClient := commandLine.newTrelloClient(boardFile)
+	trello, err := trello.NewTrelloWithDatabaseConnection(commandLine.logger, trelloClient, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Trello", zap.Error(err))
+	}
+	report, err := trello.StoreTransitions()
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the card transitions from Trello", zap.Error(err))
+	}
+	printSyncReport(report)
+}
+
+// storeTrelloBoardIncremental downloads and stores the Trello Card entries of the boards modified since the last sync in the database.
+func (commandLine *CommandLine) storeTrelloBoardIncremental(preservedColumns []string) {
+	fmt.Println("Execute: Store Trello Board incrementally.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	trelloClient := commandLine.newTrelloApiClient()
+	trello, err := trello.NewTrelloWithDatabaseConnection(commandLine.logger, trelloClient, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Trello", zap.Error(err))
+	}
+	labelsReport, report, modifiedBoards, err := trello.StoreIncremental(trelloClient, preservedColumns)
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the modified boards from Trello", zap.Error(err))
+	}
+	fmt.Printf("Modified boards: %d.\n", modifiedBoards)
+	if modifiedBoards == 0 {
+		return
+	}
+	fmt.Print("Labels. ")
+	printSyncReport(labelsReport)
+	fmt.Print("Cards. ")
+	printSyncReport(report)
+}
+
+// storeTrelloCardTransitionsIncremental downloads and stores the Trello card transition entries since the last sync in the database.
+func (commandLine *CommandLine) storeTrelloCardTransitionsIncremental() {
+	fmt.Println("Execute: Store Trello card transitions incrementally.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	trelloClient := commandLine.newTrelloApiClient()
+	trello, err := trello.NewTrelloWithDatabaseConnection(commandLine.logger, trelloClient, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Trello", zap.Error(err))
+	}
+	report, err := trello.StoreTransitionsIncremental(trelloClient)
+	if err != nil {
+		commandLine.logger.Fatal("Error retrieving and storing the card transitions from Trello", zap.Error(err))
+	}
+	printSyncReport(report)
+}
+
+// reportTrelloEstimation compares the estimates of the Trello cards stored in the database to the tracked time.
+func (commandLine *CommandLine) reportTrelloEstimation() {
+	fmt.Println("Execute: Report Trello card estimation accuracy.")
+	postgresqlConnection := initPostgresqlConnection(commandLine.config, commandLine.logger)
+	defer commandLine.closePostgresqlConnection(postgresqlConnection)
+	estimation, err := estimation.NewEstimation(commandLine.config, commandLine.logger, postgresqlConnection.GetDb())
+	if err != nil {
+		commandLine.logger.Fatal("Error creating Estimation", zap.Error(err))
+	}
+	summary, err := estimation.Report()
+	if err != nil {
+		commandLine.logger.Fatal("Error reporting the estimation accuracy of the Trello cards", zap.Error(err))
+	}
+	fmt.Printf("Estimated cards: %d, overrun: %d, tracked/estimated ratio: %.2f.\n", summary.Cards, summary.Overrun, summary.Ratio)
+	fmt.Println("The estimation accuracy is listed in the files trello_estimation_accuracy.csv and trello_estimation_trend.csv.")
+}
+
+// newBoardFileFlag defines the flag of the Trello board JSON export that replaces the Trello API.
+func newBoardFileFlag(flagSet *flag.FlagSet) *string {
+	return flagSet.String("board-file", "", "read the cards from a Trello board JSON export instead of the Trello API")
+}
+
+// newTrelloClient creates the Trello client with the Trello API base URL in the configuration,
+// or the Trello client of the board JSON export when the boardFile is defined.
+func (commandLine *CommandLine) newTrelloClient(boardFile string) trello.Client {
+	if boardFile != "" {
+		return trello.NewTrelloExportClient(commandLine.config, commandLine.logger, boardFile)
+	}
+	return commandLine.newTrelloApiClient()
+}
+
+// newTrelloApiClient creates the Trello client of the Trello API with the base URL in the configuration.
+func (commandLine *CommandLine) newTrelloApiClient() *trello.TrelloClient {
+	client := trelloLib.NewClient(commandLine.config.TrelloConfiguration.AppKey, commandLine.config.TrelloConfiguration.ApiToken)
+	if commandLine.config.TrelloConfiguration.BaseUrl != "" {
+		client.BaseURL = strings.TrimSuffix(commandLine.config.TrelloConfiguration.BaseUrl, "/")
+	}
+	client.Client = commandLine.newHttpClient()
+	return trello.NewTrelloClient(commandLine.config, commandLine.logger, client)
+}
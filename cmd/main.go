@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"log"
+	"os"
 
 	"github.com/sitMCella/toggl-trello-kpi/cli"
 	"github.com/sitMCella/toggl-trello-kpi/configuration"
 	"github.com/sitMCella/toggl-trello-kpi/logger"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -20,5 +23,12 @@ func main() {
 	}
 
 	commandLine := cli.NewCommandLine(config, logger)
-	commandLine.Execute()
+	err = commandLine.Execute(os.Args[1:])
+	if err != nil {
+		var usageError *cli.UsageError
+		if errors.As(err, &usageError) {
+			os.Exit(2)
+		}
+		logger.Fatal("Couldn't execute the command", zap.Error(err))
+	}
 }
@@ -3,6 +3,9 @@ package configuration
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/ory/viper"
 )
@@ -14,29 +17,135 @@ type Configuration struct {
 	TrelloConfiguration
 	DBConfiguration
 	GrafanaConfiguration
+	LinkingConfiguration
+	EstimationConfiguration
 }
 
 // ApplicationConfiguration struct defines the application configuration properties.
 type ApplicationConfiguration struct {
-	LogLevel string
+	LogLevel             string
+	HttpMode             string
+	HttpFixturesPath     string
+	HttpTimeoutInSeconds int
 }
 
 // TogglConfiguration struct defines the Toggl configuration properties.
 type TogglConfiguration struct {
-	ApiToken string
+	ApiToken             string
+	ApiVersion           string
+	BaseUrl              string
+	RequestsPerSecond    float64
+	MaxRetries           int
+	WindowInDays         int
+	Concurrency          int
+	MaxEntriesPerRequest int
+	// ReportsBaseUrl defines the base URL of the Toggl Reports API v3, used when the ApiVersion is "reports".
+	ReportsBaseUrl string
+	// WorkspaceIds defines the workspaces of the Toggl Reports API. All the workspaces of the user are used when empty.
+	WorkspaceIds []uint64
+	// UserIds, ProjectIds and ClientIds filter the time entries retrieved with the Toggl Reports API.
+	UserIds    []uint64
+	ProjectIds []uint64
+	ClientIds  []uint64
+	// ExportDateFormat defines the date format of the Toggl detailed report exports, e.g. "DD/MM/YYYY". The format is detected when empty.
+	ExportDateFormat string
+	// ExportTimezone defines the time zone of the dates and times in the Toggl detailed report CSV exports.
+	ExportTimezone string
+	// RunningEntries defines how the time entries of the running timers are retrieved, either "provisional" or "skip".
+	RunningEntries string
 }
 
 // TrelloConfiguration struct define the Trello configuration properties.
 type TrelloConfiguration struct {
 	AppKey             string
 	ApiToken           string
+	BaseUrl            string
 	BoardId            string
 	LabelProjectColor  []string
 	LabelCustomerColor []string
 	LabelTeamColor     []string
 	LabelCardTypeColor []string
+	// WorkflowTodoLists, WorkflowInProgressLists and WorkflowDoneLists map the Trello list names to the workflow states.
+	WorkflowTodoLists       []string
+	WorkflowInProgressLists []string
+	WorkflowDoneLists       []string
+	Dimensions              []TrelloDimensionConfiguration
+	// DimensionPolicy defines the policy of the dimensions without a policy.
+	DimensionPolicy string
+	// EstimateCustomField defines the name of the Trello custom field that contains the card estimate.
+	EstimateCustomField string
+	// EstimateNameRegex and EstimateLabelRegex define the regular expressions that extract the card estimate from the card name and the labels.
+	EstimateNameRegex  string
+	EstimateLabelRegex string
+	Boards             []TrelloBoardConfiguration
 }
 
+// TrelloBoardConfiguration struct defines the configuration properties of a Trello board.
+// The properties not defined for the board are inherited from the TrelloConfiguration.
+// The Dimensions of the board always include the "project", "customer", "team" and "type" dimensions defined by the label colors, unless redefined.
+type TrelloBoardConfiguration struct {
+	Id                      string                         `mapstructure:"ID"`
+	LabelProjectColor       []string                       `mapstructure:"LABEL_PROJECT_COLOR"`
+	LabelCustomerColor      []string                       `mapstructure:"LABEL_CUSTOMER_COLOR"`
+	LabelTeamColor          []string                       `mapstructure:"LABEL_TEAM_COLOR"`
+	LabelCardTypeColor      []string                       `mapstructure:"LABEL_CARD_TYPE_COLOR"`
+	WorkflowTodoLists       []string                       `mapstructure:"WORKFLOW_TODO_LISTS"`
+	WorkflowInProgressLists []string                       `mapstructure:"WORKFLOW_IN_PROGRESS_LISTS"`
+	WorkflowDoneLists       []string                       `mapstructure:"WORKFLOW_DONE_LISTS"`
+	Dimensions              []TrelloDimensionConfiguration `mapstructure:"DIMENSIONS"`
+	EstimateCustomField     string                         `mapstructure:"ESTIMATE_CUSTOM_FIELD"`
+	EstimateNameRegex       string                         `mapstructure:"ESTIMATE_NAME_REGEX"`
+	EstimateLabelRegex      string                         `mapstructure:"ESTIMATE_LABEL_REGEX"`
+}
+
+// TrelloDimensionConfiguration struct defines a named classification of the Trello cards by label.
+// A label belongs to the dimension when its color is one of the LabelColors, its name matches the LabelRegex, or its name starts with the LabelPrefix.
+// The Policy defines the values kept when multiple labels of a card belong to the dimension, and the Weights split the working hours between the values.
+type TrelloDimensionConfiguration struct {
+	Name        string                               `mapstructure:"NAME"`
+	LabelColors []string                             `mapstructure:"LABEL_COLORS"`
+	LabelRegex  string                               `mapstructure:"LABEL_REGEX"`
+	LabelPrefix string                               `mapstructure:"LABEL_PREFIX"`
+	Policy      string                               `mapstructure:"POLICY"`
+	Weights     []TrelloDimensionWeightConfiguration `mapstructure:"WEIGHTS"`
+	// CustomField defines the name of the Trello custom field that contains the dimension value. The labels are used when the card has no value for the custom field.
+	CustomField string `mapstructure:"CUSTOM_FIELD"`
+}
+
+// TrelloDimensionWeightConfiguration struct defines the weight of a dimension value. The values without a weight have weight 1.
+type TrelloDimensionWeightConfiguration struct {
+	Value  string  `mapstructure:"VALUE"`
+	Weight float64 `mapstructure:"WEIGHT"`
+}
+
+// The policies for the cards with multiple labels that belong to the same dimension.
+const (
+	// DimensionPolicyAll keeps all the values.
+	DimensionPolicyAll = "all"
+	// DimensionPolicyFirst keeps the value of the first label.
+	DimensionPolicyFirst = "first"
+	// DimensionPolicyLast keeps the value of the last label.
+	DimensionPolicyLast = "last"
+	// DimensionPolicyError fails the retrieval of the cards.
+	DimensionPolicyError = "error"
+)
+
+// The handling of the Toggl time entries of the running timers.
+const (
+	// RunningEntriesProvisional retrieves the running time entries with the duration computed up to now.
+	RunningEntriesProvisional = "provisional"
+	// RunningEntriesSkip skips the running time entries.
+	RunningEntriesSkip = "skip"
+)
+
+// The names of the dimensions stored in the "trello_card" columns with the same name.
+const (
+	DimensionProject  = "project"
+	DimensionCustomer = "customer"
+	DimensionTeam     = "team"
+	DimensionType     = "type"
+)
+
 // DBConfiguration struct defines the database configuration properties.
 type DBConfiguration struct {
 	Host                           string
@@ -47,6 +156,8 @@ type DBConfiguration struct {
 	MaxOpenConnections             int
 	MaxIdleConnections             int
 	ConnectionMaxLifeTimeInMinutes int
+	// AutoMigrate enables the migrations on every command that accesses the database, other than the "migrate" commands.
+	AutoMigrate bool
 }
 
 // GrafanaConfiguration struc defines the Grafana configuration properties.
@@ -56,6 +167,19 @@ type GrafanaConfiguration struct {
 	EndMonth   string
 }
 
+// LinkingConfiguration struct defines the linking configuration properties between Toggl time entries and Trello cards.
+type LinkingConfiguration struct {
+	FuzzyThreshold float64
+}
+
+// EstimationConfiguration struct defines the configuration properties of the comparison between the Trello card estimates and the Toggl tracked time.
+type EstimationConfiguration struct {
+	// HoursPerUnit defines the working hours of an estimate unit, e.g. of a story point.
+	HoursPerUnit float64
+	// OverrunTolerance defines the fraction of the estimate that the tracked time can exceed before a card is reported as overrun.
+	OverrunTolerance float64
+}
+
 // FileNotExistsError defines the file not exists error.
 type FileNotExistsError struct {
 	SettingsFilePath string
@@ -65,6 +189,26 @@ func (err *FileNotExistsError) Error() string {
 	return fmt.Sprintf("The Configuration settings file %s does not exist.", err.SettingsFilePath)
 }
 
+// InvalidTrelloDimensionError defines the invalid Trello dimension configuration error.
+type InvalidTrelloDimensionError struct {
+	Name    string
+	Message string
+}
+
+func (err *InvalidTrelloDimensionError) Error() string {
+	return fmt.Sprintf("The Trello dimension %q is not valid: %s.", err.Name, err.Message)
+}
+
+// InvalidEstimateRegexError defines the invalid Trello estimate regular expression error.
+type InvalidEstimateRegexError struct {
+	Property string
+	Message  string
+}
+
+func (err *InvalidEstimateRegexError) Error() string {
+	return fmt.Sprintf("The Trello estimate regular expression %s is not valid: %s.", err.Property, err.Message)
+}
+
 // ConfigurationSettingsError defines the configuration settings error.
 type ConfigurationSettingsError struct {
 	err error
@@ -89,15 +233,22 @@ func NewConfiguration() (Configuration, error) {
 	viper.AutomaticEnv()
 	applicationConfiguration := newApplicationConfiguration(viper.GetViper())
 	togglConfiguration := newTogglConfiguration(viper.GetViper())
-	trelloConfiguration := newTrelloConfiguration(viper.GetViper())
+	trelloConfiguration, err := newTrelloConfiguration(viper.GetViper())
+	if err != nil {
+		return Configuration{}, &ConfigurationSettingsError{err: err}
+	}
 	dbConfiguration := newDatabaseConfiguration(viper.GetViper())
 	grafanaConfiguration := newGrafanaConfiguration(viper.GetViper())
+	linkingConfiguration := newLinkingConfiguration(viper.GetViper())
+	estimationConfiguration := newEstimationConfiguration(viper.GetViper())
 	return Configuration{
 		ApplicationConfiguration: applicationConfiguration,
 		TogglConfiguration:       togglConfiguration,
 		TrelloConfiguration:      trelloConfiguration,
 		DBConfiguration:          dbConfiguration,
 		GrafanaConfiguration:     grafanaConfiguration,
+		LinkingConfiguration:     linkingConfiguration,
+		EstimationConfiguration:  estimationConfiguration,
 	}, nil
 }
 
@@ -110,35 +261,297 @@ func verifyConfigurationSettingsFileExists(settingsFilePath string) error {
 
 func newApplicationConfiguration(viper *viper.Viper) ApplicationConfiguration {
 	applicationLogLevel := viper.GetString("APPLICATION_LOG_LEVEL")
+	httpMode := viper.GetString("HTTP_MODE")
+	if httpMode == "" {
+		httpMode = "live"
+	}
+	httpFixturesPath := viper.GetString("HTTP_FIXTURES_PATH")
+	if httpFixturesPath == "" {
+		httpFixturesPath = "./fixtures"
+	}
+	httpTimeoutInSeconds := viper.GetInt("HTTP_TIMEOUT_IN_SECONDS")
+	if httpTimeoutInSeconds <= 0 {
+		httpTimeoutInSeconds = 30
+	}
 	return ApplicationConfiguration{
-		LogLevel: applicationLogLevel,
+		LogLevel:             applicationLogLevel,
+		HttpMode:             httpMode,
+		HttpFixturesPath:     httpFixturesPath,
+		HttpTimeoutInSeconds: httpTimeoutInSeconds,
 	}
 }
 
 func newTogglConfiguration(viper *viper.Viper) TogglConfiguration {
 	apiToken := viper.GetString("TOGGL_API_TOKEN")
+	apiVersion := viper.GetString("TOGGL_API_VERSION")
+	if apiVersion == "" {
+		apiVersion = "v9"
+	}
+	baseUrl := viper.GetString("TOGGL_API_BASE_URL")
+	requestsPerSecond := 1.0
+	if viper.IsSet("TOGGL_REQUESTS_PER_SECOND") {
+		requestsPerSecond = viper.GetFloat64("TOGGL_REQUESTS_PER_SECOND")
+	}
+	maxRetries := 5
+	if viper.IsSet("TOGGL_MAX_RETRIES") {
+		maxRetries = viper.GetInt("TOGGL_MAX_RETRIES")
+	}
+	windowInDays := 7
+	if viper.IsSet("TOGGL_WINDOW_IN_DAYS") {
+		windowInDays = viper.GetInt("TOGGL_WINDOW_IN_DAYS")
+	}
+	concurrency := viper.GetInt("TOGGL_CONCURRENCY")
+	if concurrency <= 0 {
+		concurrency = 2
+	}
+	maxEntriesPerRequest := viper.GetInt("TOGGL_MAX_ENTRIES_PER_REQUEST")
+	if maxEntriesPerRequest <= 0 {
+		maxEntriesPerRequest = 1000
+	}
+	reportsBaseUrl := viper.GetString("TOGGL_REPORTS_API_BASE_URL")
+	exportDateFormat := viper.GetString("TOGGL_EXPORT_DATE_FORMAT")
+	exportTimezone := viper.GetString("TOGGL_EXPORT_TIMEZONE")
+	if exportTimezone == "" {
+		exportTimezone = "UTC"
+	}
+	runningEntries := viper.GetString("TOGGL_RUNNING_ENTRIES")
+	if runningEntries == "" {
+		runningEntries = RunningEntriesProvisional
+	}
 	return TogglConfiguration{
-		ApiToken: apiToken,
+		ApiToken:             apiToken,
+		ApiVersion:           apiVersion,
+		BaseUrl:              baseUrl,
+		RequestsPerSecond:    requestsPerSecond,
+		MaxRetries:           maxRetries,
+		WindowInDays:         windowInDays,
+		Concurrency:          concurrency,
+		MaxEntriesPerRequest: maxEntriesPerRequest,
+		ReportsBaseUrl:       reportsBaseUrl,
+		WorkspaceIds:         getIds(viper, "TOGGL_WORKSPACE_IDS"),
+		UserIds:              getIds(viper, "TOGGL_USER_IDS"),
+		ProjectIds:           getIds(viper, "TOGGL_PROJECT_IDS"),
+		ClientIds:            getIds(viper, "TOGGL_CLIENT_IDS"),
+		ExportDateFormat:     exportDateFormat,
+		ExportTimezone:       exportTimezone,
+		RunningEntries:       runningEntries,
+	}
+}
+
+// getIds returns the list of IDs of the property, ignoring the values that are not positive integers.
+func getIds(viper *viper.Viper, key string) []uint64 {
+	var ids []uint64
+	for _, value := range viper.GetStringSlice(key) {
+		id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err == nil && id > 0 {
+			ids = append(ids, id)
+		}
 	}
+	return ids
 }
 
-func newTrelloConfiguration(viper *viper.Viper) TrelloConfiguration {
+func newTrelloConfiguration(viper *viper.Viper) (TrelloConfiguration, error) {
 	appKey := viper.GetString("TRELLO_APP_KEY")
 	apiToken := viper.GetString("TRELLO_API_TOKEN")
+	baseUrl := viper.GetString("TRELLO_API_BASE_URL")
 	boardId := viper.GetString("TRELLO_BOARD_ID")
 	labelProjectColor := viper.GetStringSlice("TRELLO_LABEL_PROJECT_COLOR")
 	labelCustomerColor := viper.GetStringSlice("TRELLO_LABEL_CUSTOMER_COLOR")
 	labelTeamColor := viper.GetStringSlice("TRELLO_LABEL_TEAM_COLOR")
 	labelCardTypeColor := viper.GetStringSlice("TRELLO_LABEL_CARD_TYPE_COLOR")
-	return TrelloConfiguration{
-		AppKey:             appKey,
-		ApiToken:           apiToken,
-		BoardId:            boardId,
-		LabelProjectColor:  labelProjectColor,
-		LabelCustomerColor: labelCustomerColor,
-		LabelTeamColor:     labelTeamColor,
-		LabelCardTypeColor: labelCardTypeColor,
+	workflowTodoLists := viper.GetStringSlice("TRELLO_WORKFLOW_TODO_LISTS")
+	workflowInProgressLists := viper.GetStringSlice("TRELLO_WORKFLOW_IN_PROGRESS_LISTS")
+	workflowDoneLists := viper.GetStringSlice("TRELLO_WORKFLOW_DONE_LISTS")
+	estimateCustomField := viper.GetString("TRELLO_ESTIMATE_CUSTOM_FIELD")
+	estimateNameRegex := viper.GetString("TRELLO_ESTIMATE_NAME_REGEX")
+	estimateLabelRegex := viper.GetString("TRELLO_ESTIMATE_LABEL_REGEX")
+	dimensionPolicy := viper.GetString("TRELLO_DIMENSION_POLICY")
+	if dimensionPolicy == "" {
+		dimensionPolicy = DimensionPolicyAll
+	}
+	var dimensions []TrelloDimensionConfiguration
+	err := viper.UnmarshalKey("TRELLO_DIMENSIONS", &dimensions)
+	if err != nil {
+		return TrelloConfiguration{}, err
+	}
+	var boards []TrelloBoardConfiguration
+	err = viper.UnmarshalKey("TRELLO_BOARDS", &boards)
+	if err != nil {
+		return TrelloConfiguration{}, err
+	}
+	if len(boards) == 0 && boardId != "" {
+		boards = []TrelloBoardConfiguration{{Id: boardId}}
+	}
+	trelloConfiguration := TrelloConfiguration{
+		AppKey:                  appKey,
+		ApiToken:                apiToken,
+		BaseUrl:                 baseUrl,
+		BoardId:                 boardId,
+		LabelProjectColor:       labelProjectColor,
+		LabelCustomerColor:      labelCustomerColor,
+		LabelTeamColor:          labelTeamColor,
+		LabelCardTypeColor:      labelCardTypeColor,
+		WorkflowTodoLists:       workflowTodoLists,
+		WorkflowInProgressLists: workflowInProgressLists,
+		WorkflowDoneLists:       workflowDoneLists,
+		Dimensions:              dimensions,
+		DimensionPolicy:         dimensionPolicy,
+		EstimateCustomField:     estimateCustomField,
+		EstimateNameRegex:       estimateNameRegex,
+		EstimateLabelRegex:      estimateLabelRegex,
+	}
+	for i := range boards {
+		boards[i], err = trelloConfiguration.BoardConfiguration(boards[i])
+		if err != nil {
+			return TrelloConfiguration{}, err
+		}
 	}
+	trelloConfiguration.Boards = boards
+	return trelloConfiguration, nil
+}
+
+// BoardConfiguration returns the configuration of the Trello board, with the properties not defined for the board inherited from the TrelloConfiguration.
+func (trelloConfiguration TrelloConfiguration) BoardConfiguration(board TrelloBoardConfiguration) (TrelloBoardConfiguration, error) {
+	if board.LabelProjectColor == nil {
+		board.LabelProjectColor = trelloConfiguration.LabelProjectColor
+	}
+	if board.LabelCustomerColor == nil {
+		board.LabelCustomerColor = trelloConfiguration.LabelCustomerColor
+	}
+	if board.LabelTeamColor == nil {
+		board.LabelTeamColor = trelloConfiguration.LabelTeamColor
+	}
+	if board.LabelCardTypeColor == nil {
+		board.LabelCardTypeColor = trelloConfiguration.LabelCardTypeColor
+	}
+	if board.WorkflowTodoLists == nil {
+		board.WorkflowTodoLists = trelloConfiguration.WorkflowTodoLists
+	}
+	if board.WorkflowInProgressLists == nil {
+		board.WorkflowInProgressLists = trelloConfiguration.WorkflowInProgressLists
+	}
+	if board.WorkflowDoneLists == nil {
+		board.WorkflowDoneLists = trelloConfiguration.WorkflowDoneLists
+	}
+	if board.Dimensions == nil {
+		board.Dimensions = trelloConfiguration.Dimensions
+	}
+	if board.EstimateCustomField == "" {
+		board.EstimateCustomField = trelloConfiguration.EstimateCustomField
+	}
+	if board.EstimateNameRegex == "" {
+		board.EstimateNameRegex = trelloConfiguration.EstimateNameRegex
+	}
+	if board.EstimateLabelRegex == "" {
+		board.EstimateLabelRegex = trelloConfiguration.EstimateLabelRegex
+	}
+	err := validateEstimateRegex("ESTIMATE_NAME_REGEX", board.EstimateNameRegex)
+	if err != nil {
+		return TrelloBoardConfiguration{}, err
+	}
+	err = validateEstimateRegex("ESTIMATE_LABEL_REGEX", board.EstimateLabelRegex)
+	if err != nil {
+		return TrelloBoardConfiguration{}, err
+	}
+	board.Dimensions = withDefaultPolicy(withLabelColorDimensions(board), trelloConfiguration.DimensionPolicy)
+	err = validateDimensions(board.Dimensions)
+	if err != nil {
+		return TrelloBoardConfiguration{}, err
+	}
+	return board, nil
+}
+
+// withLabelColorDimensions adds the "project", "customer", "team" and "type" dimensions defined by the label colors of the board, unless already defined.
+// The label colors are also used by these dimensions when they are defined only by a custom field.
+func withLabelColorDimensions(board TrelloBoardConfiguration) []TrelloDimensionConfiguration {
+	labelColorDimensions := []TrelloDimensionConfiguration{
+		{Name: DimensionProject, LabelColors: board.LabelProjectColor},
+		{Name: DimensionCustomer, LabelColors: board.LabelCustomerColor},
+		{Name: DimensionTeam, LabelColors: board.LabelTeamColor},
+		{Name: DimensionType, LabelColors: board.LabelCardTypeColor},
+	}
+	var dimensions []TrelloDimensionConfiguration
+	for _, labelColorDimension := range labelColorDimensions {
+		if !hasDimension(board.Dimensions, labelColorDimension.Name) {
+			dimensions = append(dimensions, labelColorDimension)
+		}
+	}
+	for _, dimension := range board.Dimensions {
+		for _, labelColorDimension := range labelColorDimensions {
+			if dimension.Name == labelColorDimension.Name && dimension.LabelColors == nil && dimension.LabelRegex == "" && dimension.LabelPrefix == "" {
+				dimension.LabelColors = labelColorDimension.LabelColors
+			}
+		}
+		dimensions = append(dimensions, dimension)
+	}
+	return dimensions
+}
+
+// withDefaultPolicy sets the policy of the dimensions without a policy.
+func withDefaultPolicy(dimensions []TrelloDimensionConfiguration, policy string) []TrelloDimensionConfiguration {
+	var result = make([]TrelloDimensionConfiguration, len(dimensions))
+	for i, dimension := range dimensions {
+		if dimension.Policy == "" {
+			dimension.Policy = policy
+		}
+		result[i] = dimension
+	}
+	return result
+}
+
+func hasDimension(dimensions []TrelloDimensionConfiguration, name string) bool {
+	for _, dimension := range dimensions {
+		if dimension.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDimensions verifies that every dimension has a unique name, a valid label regular expression, a supported policy and positive weights.
+func validateDimensions(dimensions []TrelloDimensionConfiguration) error {
+	names := make(map[string]bool)
+	for _, dimension := range dimensions {
+		if dimension.Name == "" {
+			return &InvalidTrelloDimensionError{Name: dimension.Name, Message: "the name is missing"}
+		}
+		if names[dimension.Name] {
+			return &InvalidTrelloDimensionError{Name: dimension.Name, Message: "the name is duplicated"}
+		}
+		names[dimension.Name] = true
+		if dimension.LabelRegex != "" {
+			_, err := regexp.Compile(dimension.LabelRegex)
+			if err != nil {
+				return &InvalidTrelloDimensionError{Name: dimension.Name, Message: err.Error()}
+			}
+		}
+		switch dimension.Policy {
+		case DimensionPolicyAll, DimensionPolicyFirst, DimensionPolicyLast, DimensionPolicyError:
+		default:
+			return &InvalidTrelloDimensionError{Name: dimension.Name, Message: fmt.Sprintf("the policy %q is not supported, choose from 'all', 'first', 'last' and 'error'", dimension.Policy)}
+		}
+		for _, weight := range dimension.Weights {
+			if weight.Weight <= 0 {
+				return &InvalidTrelloDimensionError{Name: dimension.Name, Message: fmt.Sprintf("the weight of the value %q must be greater than zero", weight.Value)}
+			}
+		}
+	}
+	return nil
+}
+
+// validateEstimateRegex verifies that the estimate regular expression is valid, and that its first group captures the estimate.
+func validateEstimateRegex(property string, value string) error {
+	if value == "" {
+		return nil
+	}
+	estimateRegexp, err := regexp.Compile(value)
+	if err != nil {
+		return &InvalidEstimateRegexError{Property: property, Message: err.Error()}
+	}
+	if estimateRegexp.NumSubexp() == 0 {
+		return &InvalidEstimateRegexError{Property: property, Message: "the regular expression has no group for the estimate"}
+	}
+	return nil
 }
 
 func newDatabaseConfiguration(viper *viper.Viper) DBConfiguration {
@@ -150,6 +563,7 @@ func newDatabaseConfiguration(viper *viper.Viper) DBConfiguration {
 	maxOpenConnections := viper.GetInt("DATABASE_MAX_OPEN_CONNECTIONS")
 	maxIdleConnections := viper.GetInt("DATABASE_MAX_IDLE_CONNECTIONS")
 	connectionMaxLifeTimeInMinutes := viper.GetInt("DATABASE_MAX_LIFETIME_IN_MINUTES")
+	autoMigrate := viper.GetBool("DATABASE_AUTO_MIGRATE")
 	return DBConfiguration{
 		Host:                           databaseHost,
 		Port:                           databasePort,
@@ -159,6 +573,7 @@ func newDatabaseConfiguration(viper *viper.Viper) DBConfiguration {
 		MaxOpenConnections:             maxOpenConnections,
 		MaxIdleConnections:             maxIdleConnections,
 		ConnectionMaxLifeTimeInMinutes: connectionMaxLifeTimeInMinutes,
+		AutoMigrate:                    autoMigrate,
 	}
 }
 
@@ -172,3 +587,22 @@ func newGrafanaConfiguration(viper *viper.Viper) GrafanaConfiguration {
 		EndMonth:   grafanaEndMonth,
 	}
 }
+
+func newLinkingConfiguration(viper *viper.Viper) LinkingConfiguration {
+	fuzzyThreshold := viper.GetFloat64("LINKING_FUZZY_THRESHOLD")
+	return LinkingConfiguration{
+		FuzzyThreshold: fuzzyThreshold,
+	}
+}
+
+func newEstimationConfiguration(viper *viper.Viper) EstimationConfiguration {
+	hoursPerUnit := viper.GetFloat64("ESTIMATION_HOURS_PER_UNIT")
+	if hoursPerUnit <= 0 {
+		hoursPerUnit = 1
+	}
+	overrunTolerance := viper.GetFloat64("ESTIMATION_OVERRUN_TOLERANCE")
+	return EstimationConfiguration{
+		HoursPerUnit:     hoursPerUnit,
+		OverrunTolerance: overrunTolerance,
+	}
+}
@@ -0,0 +1,203 @@
+// Package estimation provides the service for comparing the estimates of the Trello cards to the Toggl tracked time.
+package estimation
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// Estimation struct defines the service that compares the estimates of the Trello cards stored in the database to the tracked time.
+type Estimation struct {
+	logger             *zap.Logger
+	configuration      configuration.EstimationConfiguration
+	databaseConnection *sql.DB
+}
+
+// CardEstimationEntry struct defines the estimate and the tracked time of a Trello card.
+type CardEstimationEntry struct {
+	Card_id         string
+	Name            string
+	Board_name      string
+	Customer        string
+	Team            string
+	Type            string
+	Status          string
+	Estimate        float64
+	Estimated_hours float64
+	Tracked_hours   float64
+	Ratio           float64
+	Overrun         bool
+	Last_tracked_at time.Time
+}
+
+// EstimationTrendEntry struct defines the estimation accuracy of the Trello cards per month, type and team.
+// The month is the month of the last time entry tracked on the cards.
+type EstimationTrendEntry struct {
+	Month           string
+	Type            string
+	Team            string
+	Cards           int64
+	Overrun_cards   int64
+	Estimated_hours float64
+	Tracked_hours   float64
+	Ratio           float64
+}
+
+// EstimationSummary struct defines the result of the estimation report.
+type EstimationSummary struct {
+	Cards   int
+	Overrun int
+	Ratio   float64
+}
+
+// EmptyCardEstimationsError defines the empty estimated Trello cards error.
+type EmptyCardEstimationsError struct {
+}
+
+func (error *EmptyCardEstimationsError) Error() string {
+	return "There are no estimated Trello cards in the database."
+}
+
+// trendKey struct defines the grouping of the estimation trend.
+type trendKey struct {
+	month    string
+	cardType string
+	team     string
+}
+
+// NewEstimation creates a new Estimation.
+func NewEstimation(config configuration.Configuration, logger *zap.Logger, databaseConnection *sql.DB) (*Estimation, error) {
+	if logger == nil {
+		return nil, &application_errors.NilParameterError{ParameterName: "logger"}
+	}
+	if databaseConnection == nil {
+		return nil, &application_errors.NilParameterError{ParameterName: "databaseConnection"}
+	}
+	return &Estimation{
+		logger:             logger,
+		configuration:      config.EstimationConfiguration,
+		databaseConnection: databaseConnection,
+	}, nil
+}
+
+// Report writes the estimation accuracy of the estimated Trello cards in the "trello_estimation_accuracy.csv" file,
+// and the estimation accuracy per month, type and team in the "trello_estimation_trend.csv" file.
+func (estimation *Estimation) Report() (summary EstimationSummary, err error) {
+	cardEstimations, err := estimation.retrieveCardEstimations()
+	if err != nil {
+		return
+	}
+	if len(cardEstimations) == 0 {
+		return summary, &EmptyCardEstimationsError{}
+	}
+	var estimatedHours, trackedHours float64
+	cardEntries := make([]interface{}, len(cardEstimations))
+	for i, cardEstimation := range cardEstimations {
+		summary.Cards++
+		if cardEstimation.Overrun {
+			summary.Overrun++
+		}
+		estimatedHours += cardEstimation.Estimated_hours
+		trackedHours += cardEstimation.Tracked_hours
+		cardEntries[i] = cardEstimation
+	}
+	summary.Ratio = ratio(trackedHours, estimatedHours)
+	trendEntries := estimationTrend(cardEstimations)
+	estimation.logger.Info("Estimation accuracy", zap.Int("cards", summary.Cards), zap.Int("overrun", summary.Overrun), zap.Int("trend entries", len(trendEntries)))
+	downloadStructAsCsv, err := storage.NewDownloadStructAsCsv(estimation.logger)
+	if err != nil {
+		return
+	}
+	err = downloadStructAsCsv.DownloadAll(cardEntries, "trello_estimation_accuracy")
+	if err != nil || len(trendEntries) == 0 {
+		return
+	}
+	err = downloadStructAsCsv.DownloadAll(trendEntries, "trello_estimation_trend")
+	return
+}
+
+func (estimation *Estimation) retrieveCardEstimations() (cardEstimations []CardEstimationEntry, err error) {
+	rows, err := estimation.databaseConnection.Query(`SELECT card_id, name, board_name, customer, team, type, status, estimate, tracked_time, last_tracked_at FROM trello_card_estimation ORDER BY card_id`)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := rows.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	for rows.Next() {
+		var cardEstimation CardEstimationEntry
+		var trackedTime int64
+		var lastTrackedAt sql.NullTime
+		err = rows.Scan(&cardEstimation.Card_id, &cardEstimation.Name, &cardEstimation.Board_name, &cardEstimation.Customer, &cardEstimation.Team,
+			&cardEstimation.Type, &cardEstimation.Status, &cardEstimation.Estimate, &trackedTime, &lastTrackedAt)
+		if err != nil {
+			return
+		}
+		cardEstimation.Estimated_hours = cardEstimation.Estimate * estimation.configuration.HoursPerUnit
+		cardEstimation.Tracked_hours = float64(trackedTime) / time.Hour.Seconds()
+		cardEstimation.Ratio = ratio(cardEstimation.Tracked_hours, cardEstimation.Estimated_hours)
+		cardEstimation.Overrun = cardEstimation.Ratio > 1+estimation.configuration.OverrunTolerance
+		cardEstimation.Last_tracked_at = lastTrackedAt.Time
+		cardEstimations = append(cardEstimations, cardEstimation)
+	}
+	err = rows.Err()
+	return
+}
+
+// estimationTrend groups the card estimations by the month of the last tracked time entry, the type and the team.
+// The cards without tracked time are not part of the trend.
+func estimationTrend(cardEstimations []CardEstimationEntry) []interface{} {
+	trend := make(map[trendKey]*EstimationTrendEntry)
+	var keys []trendKey
+	for _, cardEstimation := range cardEstimations {
+		if cardEstimation.Last_tracked_at.IsZero() {
+			continue
+		}
+		key := trendKey{month: cardEstimation.Last_tracked_at.Format("2006-01"), cardType: cardEstimation.Type, team: cardEstimation.Team}
+		trendEntry, found := trend[key]
+		if !found {
+			trendEntry = &EstimationTrendEntry{Month: key.month, Type: key.cardType, Team: key.team}
+			trend[key] = trendEntry
+			keys = append(keys, key)
+		}
+		trendEntry.Cards++
+		if cardEstimation.Overrun {
+			trendEntry.Overrun_cards++
+		}
+		trendEntry.Estimated_hours += cardEstimation.Estimated_hours
+		trendEntry.Tracked_hours += cardEstimation.Tracked_hours
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].month != keys[j].month {
+			return keys[i].month < keys[j].month
+		}
+		if keys[i].cardType != keys[j].cardType {
+			return keys[i].cardType < keys[j].cardType
+		}
+		return keys[i].team < keys[j].team
+	})
+	trendEntries := make([]interface{}, len(keys))
+	for i, key := range keys {
+		trendEntry := trend[key]
+		trendEntry.Ratio = ratio(trendEntry.Tracked_hours, trendEntry.Estimated_hours)
+		trendEntries[i] = *trendEntry
+	}
+	return trendEntries
+}
+
+// ratio returns the ratio between the tracked and the estimated hours, or zero when the estimated hours are zero.
+func ratio(trackedHours float64, estimatedHours float64) float64 {
+	if estimatedHours == 0 {
+		return 0
+	}
+	return trackedHours / estimatedHours
+}
@@ -0,0 +1,114 @@
+package estimation
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"go.uber.org/zap"
+)
+
+func TestEstimationCreateThrowsErrorOnNilLogger(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	_, err = NewEstimation(configuration.Configuration{}, nil, db)
+	verifyNilParameterError(t, err, "logger")
+}
+
+func TestEstimationCreateThrowsErrorOnNilDatabaseConnection(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	_, err = NewEstimation(configuration.Configuration{}, logger, nil)
+	verifyNilParameterError(t, err, "databaseConnection")
+}
+
+func verifyNilParameterError(t *testing.T, err error, parameterName string) {
+	if err == nil {
+		t.Fatalf("Expect an error while creating Estimation with nil %s.", parameterName)
+	}
+	switch err.(type) {
+	case *application_errors.NilParameterError:
+		return
+	default:
+		t.Errorf("Expect a NilParameterError while creating Estimation with nil %s.", parameterName)
+	}
+}
+
+func TestEstimationReport(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	config := configuration.Configuration{EstimationConfiguration: configuration.EstimationConfiguration{HoursPerUnit: 2, OverrunTolerance: 0.1}}
+	estimation, err := NewEstimation(config, logger, db)
+	if err != nil {
+		t.Fatalf("Error creating Estimation: %v", err)
+	}
+	lastTrackedAt := time.Date(2021, time.Month(02), 15, 17, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT (.+) FROM trello_card_estimation").
+		WillReturnRows(sqlmock.NewRows([]string{"card_id", "name", "board_name", "customer", "team", "type", "status", "estimate", "tracked_time", "last_tracked_at"}).
+			AddRow("45636633", "(2) Login page", "Customer board", "Acme", "Web", "Feature", "done", 2.0, 18000, lastTrackedAt).
+			AddRow("45636634", "(3) Database schema", "Customer board", "Acme", "Web", "Feature", "done", 3.0, 21600, lastTrackedAt).
+			AddRow("45636635", "(1) Deployment", "Customer board", "Acme", "Ops", "Chore", "todo", 1.0, 0, nil))
+
+	summary, err := estimation.Report()
+	if err != nil {
+		t.Fatalf("Error in Estimation Report: %v", err)
+	}
+	defer func() {
+		for _, fileName := range []string{"trello_estimation_accuracy.csv", "trello_estimation_trend.csv"} {
+			oserr := os.Remove(fileName)
+			if oserr != nil {
+				t.Fatalf("Error on removing temp file: %v", oserr)
+			}
+		}
+	}()
+	assert.Equal(t, EstimationSummary{Cards: 3, Overrun: 1, Ratio: 11.0 / 12.0}, summary, "Expected the estimation summary")
+	data, err := ioutil.ReadFile("trello_estimation_trend.csv")
+	if err != nil {
+		t.Fatalf("Error while reading the file trello_estimation_trend.csv: %v", err)
+	}
+	expectedData := `Month,Type,Team,Cards,Overrun_cards,Estimated_hours,Tracked_hours,Ratio
+2021-02,Feature,Web,2,1,10,11,1.1
+`
+	assert.Equal(t, expectedData, string(data), "Expected the estimation trend")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled database expectations: %v", err)
+	}
+}
+
+func getLogger() (*zap.Logger, error) {
+	zapCfg := zap.Config{
+		Level:       zap.NewAtomicLevelAt(zap.FatalLevel),
+		Development: false,
+		Sampling: &zap.SamplingConfig{
+			Initial:    100,
+			Thereafter: 100,
+		},
+		Encoding:         "json",
+		EncoderConfig:    zap.NewProductionEncoderConfig(),
+		OutputPaths:      []string{"stderr"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+	return zapCfg.Build()
+}
@@ -0,0 +1,221 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// DefaultTimeout defines the default timeout of an HTTP request.
+const DefaultTimeout = 30 * time.Second
+
+const initialBackoff = time.Second
+
+const maxBackoff = 30 * time.Second
+
+// maxErrorBodyLength defines the maximum length of the response body reported in the StatusError.
+const maxErrorBodyLength = 512
+
+// Client struct defines the HTTP client that limits the request rate, and retries the failed requests with exponential backoff.
+type Client struct {
+	logger     *zap.Logger
+	httpClient *http.Client
+	limiter    *rate.Limiter
+	maxRetries int
+	sleep      func(time.Duration)
+}
+
+// StatusError defines the error for an HTTP response with an unexpected status code.
+type StatusError struct {
+	Method     string
+	Url        string
+	StatusCode int
+	Body       string
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("The HTTP request %s %s failed with status code %d: %s", err.Method, err.Url, err.StatusCode, err.Body)
+}
+
+// RateLimitError defines the error for an HTTP request still rate limited by the server after all the retries.
+type RateLimitError struct {
+	StatusError
+	RetryAfter time.Duration
+}
+
+func (err *RateLimitError) Error() string {
+	return fmt.Sprintf("The HTTP request %s %s is rate limited, retry after %s.", err.Method, err.Url, err.RetryAfter)
+}
+
+// ServerError defines the error for an HTTP request still failing with a server error after all the retries.
+type ServerError struct {
+	StatusError
+}
+
+func (err *ServerError) Error() string {
+	return fmt.Sprintf("The HTTP request %s %s failed with the server error %d.", err.Method, err.Url, err.StatusCode)
+}
+
+// NewClient creates a new Client. The request rate is not limited when requestsPerSecond is not greater than zero.
+func NewClient(logger *zap.Logger, httpClient *http.Client, requestsPerSecond float64, maxRetries int) *Client {
+	limit := rate.Inf
+	if requestsPerSecond > 0 {
+		limit = rate.Limit(requestsPerSecond)
+	}
+	return &Client{
+		logger:     logger,
+		httpClient: httpClient,
+		limiter:    rate.NewLimiter(limit, 1),
+		maxRetries: maxRetries,
+		sleep:      time.Sleep,
+	}
+}
+
+// GetJson executes the HTTP GET request and decodes the JSON response body into the result.
+func (client *Client) GetJson(url string, header http.Header, result interface{}) error {
+	resp, err := client.Get(url, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Get executes the HTTP GET request, and returns the response when the status code is 200 OK.
+// The requests rate limited by the server (429) and the server errors (5xx) are retried with exponential backoff, respecting the Retry-After header.
+func (client *Client) Get(url string, header http.Header) (*http.Response, error) {
+	return client.Do("GET", url, header, nil)
+}
+
+// Post executes the HTTP POST request with the JSON body, and returns the response when the status code is 200 OK.
+// The requests are retried as the GET requests.
+func (client *Client) Post(url string, header http.Header, body interface{}) (*http.Response, error) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	postHeader := header.Clone()
+	if postHeader == nil {
+		postHeader = http.Header{}
+	}
+	postHeader.Set("Content-Type", "application/json")
+	return client.Do("POST", url, postHeader, content)
+}
+
+// Do executes the HTTP request, and returns the response when the status code is 200 OK.
+// The body is sent again on each retry.
+func (client *Client) Do(method string, url string, header http.Header, body []byte) (*http.Response, error) {
+	backoff := initialBackoff
+	for attempt := 0; ; attempt++ {
+		err := client.limiter.Wait(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, url, bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := client.httpClient.Do(req)
+		if err != nil {
+			if attempt >= client.maxRetries || !isTransientError(err) {
+				return nil, err
+			}
+			client.retry(url, attempt, backoff, zap.Error(err))
+			backoff = nextBackoff(backoff)
+			continue
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		statusError := newStatusError(req, resp)
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		if !retryable {
+			return nil, statusError
+		}
+		wait := backoff
+		if retryAfter, found := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); found {
+			wait = retryAfter
+		}
+		if attempt >= client.maxRetries {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				return nil, &RateLimitError{StatusError: *statusError, RetryAfter: wait}
+			}
+			return nil, &ServerError{StatusError: *statusError}
+		}
+		client.retry(url, attempt, wait, zap.Int("status code", resp.StatusCode))
+		backoff = nextBackoff(backoff)
+	}
+}
+
+func (client *Client) retry(url string, attempt int, wait time.Duration, reason zap.Field) {
+	client.logger.Warn("Retry the HTTP request", zap.String("url", redactedUrlString(url)), zap.Int("attempt", attempt+1), zap.Duration("wait", wait), reason)
+	client.sleep(wait)
+}
+
+// newStatusError creates the StatusError from the response, and closes the response body.
+func newStatusError(req *http.Request, resp *http.Response) *StatusError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+	return &StatusError{Method: req.Method, Url: redactedUrl(req.URL), StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// isTransientError returns true for the network errors that may succeed when the request is retried.
+func isTransientError(err error) bool {
+	var urlError *neturl.Error
+	if errors.As(err, &urlError) {
+		err = urlError.Err
+	}
+	var netError net.Error
+	return errors.As(err, &netError) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter parses the Retry-After header, either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+func redactedUrlString(url string) string {
+	parsedUrl, err := neturl.Parse(url)
+	if err != nil {
+		return ""
+	}
+	return redactedUrl(parsedUrl)
+}
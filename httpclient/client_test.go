@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"go.uber.org/zap"
+)
+
+func newTestClient(maxRetries int) (*Client, *[]time.Duration) {
+	var waits []time.Duration
+	client := NewClient(zap.NewNop(), &http.Client{}, 0, maxRetries)
+	client.sleep = func(wait time.Duration) { waits = append(waits, wait) }
+	return client, &waits
+}
+
+func TestClientRetriesRateLimitedRequestAfterRetryAfter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer server.Close()
+	client, waits := newTestClient(3)
+
+	var result struct{ Id int }
+	err := client.GetJson(server.URL, nil, &result)
+	if err != nil {
+		t.Fatalf("Error in Client GetJson: %v", err)
+	}
+	assert.Equal(t, 1, result.Id, "Expected the decoded response body")
+	assert.Equal(t, []time.Duration{7 * time.Second}, *waits, "Expected to wait for the Retry-After duration")
+}
+
+func TestClientRetriesServerErrorWithExponentialBackoff(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer server.Close()
+	client, waits := newTestClient(3)
+
+	var result struct{ Id int }
+	err := client.GetJson(server.URL, nil, &result)
+	if err != nil {
+		t.Fatalf("Error in Client GetJson: %v", err)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, *waits, "Expected the exponential backoff")
+}
+
+func TestClientThrowsRateLimitErrorAfterMaxRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	client, waits := newTestClient(2)
+
+	_, err := client.Get(server.URL, nil)
+	switch err.(type) {
+	case *RateLimitError:
+	default:
+		t.Errorf("Expect a RateLimitError after the retries, got %v", err)
+	}
+	assert.Equal(t, 2, len(*waits), "Expected two retries")
+}
+
+func TestClientThrowsStatusErrorWithoutRetryOnClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "Incorrect username and/or password")
+	}))
+	defer server.Close()
+	client, waits := newTestClient(3)
+
+	_, err := client.Get(server.URL, nil)
+	switch err := err.(type) {
+	case *StatusError:
+		assert.Equal(t, http.StatusForbidden, err.StatusCode, "Expected the response status code")
+		assert.Equal(t, "Incorrect username and/or password", err.Body, "Expected the response body")
+	default:
+		t.Errorf("Expect a StatusError on a client error, got %v", err)
+	}
+	assert.Equal(t, 0, len(*waits), "Expected no retries")
+}
+
+func TestParseRetryAfterHttpDate(t *testing.T) {
+	now := time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC)
+
+	wait, found := parseRetryAfter("Mon, 01 Feb 2021 09:00:30 GMT", now)
+	assert.Equal(t, true, found, "Expected the Retry-After date to be parsed")
+	assert.Equal(t, 30*time.Second, wait, "Expected the wait until the Retry-After date")
+}
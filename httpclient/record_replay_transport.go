@@ -0,0 +1,209 @@
+// Package httpclient provides the HTTP client shared by the Toggl and Trello clients.
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+)
+
+// Mode defines how the HTTP requests are executed.
+type Mode string
+
+const (
+	// Live executes the HTTP requests against the real services.
+	Live Mode = "live"
+	// Record executes the HTTP requests against the real services and saves the responses in the fixture files.
+	Record Mode = "record"
+	// Replay returns the responses saved in the fixture files without any network access.
+	Replay Mode = "replay"
+)
+
+// credentialParameters lists the URL query parameters that contain credentials, which are never saved in the fixture files.
+var credentialParameters = []string{"key", "token", "api_token"}
+
+var unsafeFileNameRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Fixture struct defines an HTTP response saved in a fixture file.
+type Fixture struct {
+	Method     string      `json:"method"`
+	Url        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// RecordReplayTransport implements the http.RoundTripper that records and replays the HTTP responses.
+type RecordReplayTransport struct {
+	mode         Mode
+	fixturesPath string
+	transport    http.RoundTripper
+}
+
+// UnknownModeError defines the unknown HTTP mode error.
+type UnknownModeError struct {
+	Mode string
+}
+
+func (err *UnknownModeError) Error() string {
+	return fmt.Sprintf("The HTTP mode %s is not supported, choose from 'live', 'record' and 'replay'.", err.Mode)
+}
+
+// MissingFixtureError defines the error for a replayed HTTP request without a fixture file.
+type MissingFixtureError struct {
+	Method   string
+	Url      string
+	FileName string
+}
+
+func (err *MissingFixtureError) Error() string {
+	return fmt.Sprintf("The fixture file %s for the request %s %s does not exist, run the application with the 'record' HTTP mode first.", err.FileName, err.Method, err.Url)
+}
+
+// NewHttpClient creates the HTTP client for the HTTP mode and timeout in the configuration.
+func NewHttpClient(config configuration.ApplicationConfiguration) (*http.Client, error) {
+	transport, err := NewRecordReplayTransport(Mode(config.HttpMode), config.HttpFixturesPath, http.DefaultTransport)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(config.HttpTimeoutInSeconds) * time.Second,
+	}, nil
+}
+
+// NewRecordReplayTransport creates a new RecordReplayTransport that executes the live requests with the transport.
+func NewRecordReplayTransport(mode Mode, fixturesPath string, transport http.RoundTripper) (*RecordReplayTransport, error) {
+	switch mode {
+	case Live, Record, Replay:
+	case "":
+		mode = Live
+	default:
+		return nil, &UnknownModeError{Mode: string(mode)}
+	}
+	return &RecordReplayTransport{
+		mode:         mode,
+		fixturesPath: fixturesPath,
+		transport:    transport,
+	}, nil
+}
+
+// RoundTrip executes the HTTP request according to the HTTP mode.
+func (recordReplayTransport *RecordReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch recordReplayTransport.mode {
+	case Replay:
+		return recordReplayTransport.replay(req)
+	case Record:
+		return recordReplayTransport.record(req)
+	}
+	return recordReplayTransport.transport.RoundTrip(req)
+}
+
+func (recordReplayTransport *RecordReplayTransport) replay(req *http.Request) (*http.Response, error) {
+	fileName := recordReplayTransport.fixtureFileName(req)
+	content, err := os.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, &MissingFixtureError{Method: req.Method, Url: redactedUrl(req.URL), FileName: fileName}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	err = json.Unmarshal(content, &fixture)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fixture.Header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (recordReplayTransport *RecordReplayTransport) record(req *http.Request) (resp *http.Response, err error) {
+	resp, err = recordReplayTransport.transport.RoundTrip(req)
+	if err != nil {
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	fixture := Fixture{
+		Method:     req.Method,
+		Url:        redactedUrl(req.URL),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       string(body),
+	}
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(fixture)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(recordReplayTransport.fixturesPath, 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(recordReplayTransport.fixtureFileName(req), content.Bytes(), 0644)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// fixtureFileName creates the fixture file name from the request method, URL and body, e.g. "GET_api_track_toggl_com_1a2b3c4d5e6f7a8b.json".
+// The credentials are removed from the URL, so that the fixture files don't depend on the API tokens.
+// The body is part of the name only for the requests with a body, e.g. the paginated POST requests.
+func (recordReplayTransport *RecordReplayTransport) fixtureFileName(req *http.Request) string {
+	key := req.Method + " " + redactedUrl(req.URL)
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			content, _ := io.ReadAll(body)
+			body.Close()
+			if len(content) > 0 {
+				key += " " + string(content)
+			}
+		}
+	}
+	hash := sha256.Sum256([]byte(key))
+	fileName := fmt.Sprintf("%s_%s_%s.json", req.Method, unsafeFileNameRegexp.ReplaceAllString(req.URL.Host, "_"), hex.EncodeToString(hash[:8]))
+	return filepath.Join(recordReplayTransport.fixturesPath, fileName)
+}
+
+// redactedUrl returns the URL without the user credentials and the credential query parameters, with the query parameters sorted.
+func redactedUrl(url *neturl.URL) string {
+	redacted := *url
+	redacted.User = nil
+	query := redacted.Query()
+	for _, parameter := range credentialParameters {
+		query.Del(parameter)
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
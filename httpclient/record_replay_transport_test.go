@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestRecordReplayTransportRecordsAndReplaysResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "board"}`)
+	}))
+	fixturesPath := t.TempDir()
+	recordTransport, err := NewRecordReplayTransport(Record, fixturesPath, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Error creating RecordReplayTransport: %v", err)
+	}
+	url := server.URL + "/1/boards/board?fields=all&key=app-key&token=secret-token"
+
+	recordedBody := get(t, &http.Client{Transport: recordTransport}, url)
+	server.Close()
+	replayTransport, err := NewRecordReplayTransport(Replay, fixturesPath, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Error creating RecordReplayTransport: %v", err)
+	}
+	replayedBody := get(t, &http.Client{Transport: replayTransport}, server.URL+"/1/boards/board?token=other-token&fields=all")
+
+	assert.Equal(t, `{"id": "board"}`, recordedBody, "Expected the recorded response body")
+	assert.Equal(t, recordedBody, replayedBody, "Expected the replayed response body")
+	fixtureFiles, err := os.ReadDir(fixturesPath)
+	if err != nil {
+		t.Fatalf("Error reading the fixtures path: %v", err)
+	}
+	assert.Equal(t, 1, len(fixtureFiles), "Expected one fixture file")
+	content, err := os.ReadFile(fixturesPath + "/" + fixtureFiles[0].Name())
+	if err != nil {
+		t.Fatalf("Error reading the fixture file: %v", err)
+	}
+	if strings.Contains(string(content), "secret-token") || strings.Contains(string(content), "app-key") {
+		t.Errorf("Expect the fixture file without credentials")
+	}
+}
+
+func TestRecordReplayTransportThrowsMissingFixtureErrorOnUnknownRequest(t *testing.T) {
+	replayTransport, err := NewRecordReplayTransport(Replay, t.TempDir(), http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("Error creating RecordReplayTransport: %v", err)
+	}
+	req, err := http.NewRequest("GET", "https://api.track.toggl.com/api/v9/me/time_entries", nil)
+	if err != nil {
+		t.Fatalf("Error creating the request: %v", err)
+	}
+
+	_, err = replayTransport.RoundTrip(req)
+	switch err.(type) {
+	case *MissingFixtureError:
+		return
+	default:
+		t.Errorf("Expect a MissingFixtureError while replaying a request without fixture file")
+	}
+}
+
+func TestRecordReplayTransportCreateThrowsUnknownModeError(t *testing.T) {
+	_, err := NewRecordReplayTransport("offline", t.TempDir(), http.DefaultTransport)
+	switch err.(type) {
+	case *UnknownModeError:
+		return
+	default:
+		t.Errorf("Expect an UnknownModeError while creating RecordReplayTransport with an unknown mode")
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Error executing the request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading the response body: %v", err)
+	}
+	return string(body)
+}
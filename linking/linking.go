@@ -0,0 +1,201 @@
+// Package linking provides the service for linking the Toggl time entries to the Trello cards.
+package linking
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"github.com/sitMCella/toggl-trello-kpi/trello"
+	"go.uber.org/zap"
+)
+
+// Linker struct defines the service that links the Toggl time entries stored in the database to the Trello cards.
+type Linker struct {
+	logger             *zap.Logger
+	configuration      configuration.LinkingConfiguration
+	databaseConnection *sql.DB
+}
+
+// TimeEntry struct defines the Toggl time entry fields used for the linking.
+type TimeEntry struct {
+	Id             string
+	Description    string
+	Start          time.Time
+	Tags           []string
+	Trello_card_id string
+}
+
+// LinkingReportEntry struct defines a Toggl time entry that couldn't be linked to a Trello card.
+type LinkingReportEntry struct {
+	Id          string
+	Description string
+	Start       time.Time
+	Tags        []string
+	Status      string
+	Method      string
+	Candidates  []string
+}
+
+// LinkingSummary struct defines the result of the linking.
+type LinkingSummary struct {
+	Matched   int
+	Unmatched int
+	Ambiguous int
+	Methods   map[MatchMethod]int
+}
+
+// link struct defines the Trello card assigned to a Toggl time entry.
+type link struct {
+	timeEntryId string
+	cardId      string
+}
+
+// NewLinker creates a new Linker.
+func NewLinker(config configuration.Configuration, logger *zap.Logger, databaseConnection *sql.DB) (*Linker, error) {
+	if logger == nil {
+		return nil, &application_errors.NilParameterError{ParameterName: "logger"}
+	}
+	if databaseConnection == nil {
+		return nil, &application_errors.NilParameterError{ParameterName: "databaseConnection"}
+	}
+	return &Linker{
+		logger:             logger,
+		configuration:      config.LinkingConfiguration,
+		databaseConnection: databaseConnection,
+	}, nil
+}
+
+// Link fills the Trello card ID of the Toggl time entries that start between the startTime and endTime.
+// All the time entries are processed when both startTime and endTime are zero.
+// The time entries already linked to a Trello card are processed only when relink is true.
+// The time entries that couldn't be linked are written in the "toggl_linking_report.csv" file.
+func (linker *Linker) Link(startTime time.Time, endTime time.Time, relink bool) (summary LinkingSummary, err error) {
+	summary.Methods = make(map[MatchMethod]int)
+	cards, err := linker.retrieveCards()
+	if err != nil {
+		return
+	}
+	timeEntries, err := linker.retrieveTimeEntries(startTime, endTime, relink)
+	if err != nil {
+		return
+	}
+	linker.logger.Info("Linking time entries", zap.Int("time entries", len(timeEntries)), zap.Int("cards", len(cards)))
+	matcher := NewMatcher(cards, linker.configuration.FuzzyThreshold)
+	var links []link
+	var reportEntries []interface{}
+	for _, timeEntry := range timeEntries {
+		match := matcher.Match(timeEntry.Description, timeEntry.Tags)
+		switch match.Status {
+		case Matched:
+			summary.Matched++
+			summary.Methods[match.Method]++
+			if match.CardId != timeEntry.Trello_card_id {
+				links = append(links, link{timeEntryId: timeEntry.Id, cardId: match.CardId})
+			}
+			continue
+		case Ambiguous:
+			summary.Ambiguous++
+		default:
+			summary.Unmatched++
+		}
+		reportEntries = append(reportEntries, LinkingReportEntry{
+			Id:          timeEntry.Id,
+			Description: timeEntry.Description,
+			Start:       timeEntry.Start,
+			Tags:        timeEntry.Tags,
+			Status:      string(match.Status),
+			Method:      string(match.Method),
+			Candidates:  match.Candidates,
+		})
+	}
+	err = linker.storeLinks(links)
+	if err != nil {
+		return
+	}
+	if len(reportEntries) == 0 {
+		return
+	}
+	downloadStructAsCsv, err := storage.NewDownloadStructAsCsv(linker.logger)
+	if err != nil {
+		return
+	}
+	err = downloadStructAsCsv.DownloadAll(reportEntries, "toggl_linking_report")
+	return
+}
+
+func (linker *Linker) retrieveCards() (cards []trello.TrelloCardEntry, err error) {
+	rows, err := linker.databaseConnection.Query(`SELECT id, name, short_link FROM trello_card`)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := rows.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	for rows.Next() {
+		var card trello.TrelloCardEntry
+		err = rows.Scan(&card.Id, &card.Name, &card.Short_link)
+		if err != nil {
+			return
+		}
+		cards = append(cards, card)
+	}
+	err = rows.Err()
+	return
+}
+
+func (linker *Linker) retrieveTimeEntries(startTime time.Time, endTime time.Time, relink bool) (timeEntries []TimeEntry, err error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	if !startTime.IsZero() || !endTime.IsZero() {
+		conditions = append(conditions, "start BETWEEN $1 AND $2")
+		args = append(args, startTime, endTime)
+	}
+	if !relink {
+		conditions = append(conditions, "trello_card_id = ''")
+	}
+	sqlStmt := `SELECT id, description, start, tags, trello_card_id FROM toggl_time WHERE ` + strings.Join(conditions, " AND ")
+	rows, err := linker.databaseConnection.Query(sqlStmt, args...)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := rows.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	for rows.Next() {
+		var timeEntry TimeEntry
+		err = rows.Scan(&timeEntry.Id, &timeEntry.Description, &timeEntry.Start, pq.Array(&timeEntry.Tags), &timeEntry.Trello_card_id)
+		if err != nil {
+			return
+		}
+		timeEntries = append(timeEntries, timeEntry)
+	}
+	err = rows.Err()
+	return
+}
+
+func (linker *Linker) storeLinks(links []link) error {
+	if len(links) == 0 {
+		return nil
+	}
+	return storage.WithTransaction(linker.databaseConnection, func(tx *sql.Tx) error {
+		sqlStmt := `UPDATE toggl_time SET trello_card_id = $1 WHERE id = $2`
+		for _, link := range links {
+			_, err := tx.Exec(sqlStmt, link.cardId, link.timeEntryId)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
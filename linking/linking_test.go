@@ -0,0 +1,113 @@
+package linking
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"go.uber.org/zap"
+)
+
+func TestLinkerCreateThrowsErrorOnNilLogger(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	_, err = NewLinker(configuration.Configuration{}, nil, db)
+	verifyNilParameterError(t, err, "logger")
+}
+
+func TestLinkerCreateThrowsErrorOnNilDatabaseConnection(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	_, err = NewLinker(configuration.Configuration{}, logger, nil)
+	verifyNilParameterError(t, err, "databaseConnection")
+}
+
+func verifyNilParameterError(t *testing.T, err error, parameterName string) {
+	if err == nil {
+		t.Fatalf("Expect an error while creating Linker with nil %s.", parameterName)
+	}
+	switch err.(type) {
+	case *application_errors.NilParameterError:
+		return
+	default:
+		t.Errorf("Expect a NilParameterError while creating Linker with nil %s.", parameterName)
+	}
+}
+
+func TestLinkerLink(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	config := configuration.Configuration{LinkingConfiguration: configuration.LinkingConfiguration{FuzzyThreshold: 0.85}}
+	linker, err := NewLinker(config, logger, db)
+	if err != nil {
+		t.Fatalf("Error creating Linker: %v", err)
+	}
+	start := time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id, name, short_link FROM trello_card").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "short_link"}).
+			AddRow("5f1a2b3c4d5e6f7a8b9c0d1e", "Implement the login page", "aBcD1234").
+			AddRow("5f1a2b3c4d5e6f7a8b9c0d1f", "Design the database schema", "eFgH5678"))
+	mock.ExpectQuery("SELECT id, description, start, tags, trello_card_id FROM toggl_time WHERE deleted_at IS NULL AND trello_card_id = ''").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "description", "start", "tags", "trello_card_id"}).
+			AddRow("86854567", "Implement the login page", start, "{}", "").
+			AddRow("86854568", "Weekly meeting", start, "{meeting}", ""))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE toggl_time SET trello_card_id").
+		WithArgs("5f1a2b3c4d5e6f7a8b9c0d1e", "86854567").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	summary, err := linker.Link(time.Time{}, time.Time{}, false)
+	if err != nil {
+		t.Fatalf("Error in Linker Link: %v", err)
+	}
+	reportFileName := "toggl_linking_report.csv"
+	defer func() {
+		oserr := os.Remove(reportFileName)
+		if oserr != nil {
+			t.Fatalf("Error on removing temp file: %v", oserr)
+		}
+	}()
+	if summary.Matched != 1 || summary.Unmatched != 1 || summary.Ambiguous != 0 {
+		t.Errorf("Unexpected linking summary: %+v", summary)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled database expectations: %v", err)
+	}
+}
+
+func getLogger() (*zap.Logger, error) {
+	zapCfg := zap.Config{
+		Level:       zap.NewAtomicLevelAt(zap.FatalLevel),
+		Development: false,
+		Sampling: &zap.SamplingConfig{
+			Initial:    100,
+			Thereafter: 100,
+		},
+		Encoding:         "json",
+		EncoderConfig:    zap.NewProductionEncoderConfig(),
+		OutputPaths:      []string{"stderr"},
+		ErrorOutputPaths: []string{"stderr"},
+	}
+	return zapCfg.Build()
+}
@@ -0,0 +1,227 @@
+package linking
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sitMCella/toggl-trello-kpi/trello"
+)
+
+// MatchStatus defines the result of the match between a Toggl time entry and the Trello cards.
+type MatchStatus string
+
+const (
+	Matched   MatchStatus = "matched"
+	Unmatched MatchStatus = "unmatched"
+	Ambiguous MatchStatus = "ambiguous"
+)
+
+// MatchMethod defines the rule that matched a Toggl time entry with a Trello card.
+type MatchMethod string
+
+const (
+	CardTag   MatchMethod = "tag"
+	CardUrl   MatchMethod = "url"
+	ShortLink MatchMethod = "short_link"
+	ExactName MatchMethod = "name"
+	FuzzyName MatchMethod = "fuzzy"
+	NoMethod  MatchMethod = ""
+)
+
+const cardTagName = "card:"
+
+var cardUrlRegexp = regexp.MustCompile(`https?://(?:www\.)?trello\.com/c/([A-Za-z0-9]+)`)
+
+var shortLinkRegexp = regexp.MustCompile(`\b[A-Za-z0-9]{8}\b`)
+
+// Match struct defines the match between a Toggl time entry and the Trello cards.
+type Match struct {
+	Status     MatchStatus
+	Method     MatchMethod
+	CardId     string
+	Candidates []string
+}
+
+// Matcher struct defines the rules that match a Toggl time entry with a Trello card.
+type Matcher struct {
+	cards          []trello.TrelloCardEntry
+	cardsById      map[string]trello.TrelloCardEntry
+	cardsByLink    map[string]trello.TrelloCardEntry
+	cardsByName    map[string][]trello.TrelloCardEntry
+	fuzzyThreshold float64
+}
+
+// NewMatcher creates a new Matcher. The fuzzy name match is disabled when the fuzzyThreshold is not greater than zero.
+func NewMatcher(cards []trello.TrelloCardEntry, fuzzyThreshold float64) *Matcher {
+	matcher := &Matcher{
+		cards:          cards,
+		cardsById:      make(map[string]trello.TrelloCardEntry),
+		cardsByLink:    make(map[string]trello.TrelloCardEntry),
+		cardsByName:    make(map[string][]trello.TrelloCardEntry),
+		fuzzyThreshold: fuzzyThreshold,
+	}
+	for _, card := range cards {
+		matcher.cardsById[card.Id] = card
+		if card.Short_link != "" {
+			matcher.cardsByLink[card.Short_link] = card
+		}
+		name := normalizeName(card.Name)
+		matcher.cardsByName[name] = append(matcher.cardsByName[name], card)
+	}
+	return matcher
+}
+
+// Match finds the Trello card of a Toggl time entry from the time entry description and tags.
+// The rules are applied in order: the "card:<id>" tag, the card URL, the card short link, the exact card name, and the fuzzy card name.
+func (matcher *Matcher) Match(description string, tags []string) Match {
+	if match := matcher.matchTag(tags); match.Status != Unmatched {
+		return match
+	}
+	if match := matcher.matchReferences(cardUrlRegexp.FindAllStringSubmatch(description, -1), CardUrl); match.Status != Unmatched {
+		return match
+	}
+	if match := matcher.matchShortLink(description); match.Status != Unmatched {
+		return match
+	}
+	if match := matcher.matchExactName(description); match.Status != Unmatched {
+		return match
+	}
+	return matcher.matchFuzzyName(description)
+}
+
+func (matcher *Matcher) matchTag(tags []string) Match {
+	var references [][]string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if len(tag) > len(cardTagName) && strings.EqualFold(tag[:len(cardTagName)], cardTagName) {
+			references = append(references, []string{tag, strings.TrimSpace(tag[len(cardTagName):])})
+		}
+	}
+	return matcher.matchReferences(references, CardTag)
+}
+
+// matchReferences matches the card IDs or short links captured by a regular expression.
+func (matcher *Matcher) matchReferences(references [][]string, method MatchMethod) Match {
+	var cardIds []string
+	for _, reference := range references {
+		if card, found := matcher.findCard(reference[1]); found {
+			cardIds = appendUnique(cardIds, card.Id)
+		}
+	}
+	return newMatch(cardIds, method)
+}
+
+func (matcher *Matcher) matchShortLink(description string) Match {
+	var cardIds []string
+	for _, word := range shortLinkRegexp.FindAllString(description, -1) {
+		if card, found := matcher.cardsByLink[word]; found {
+			cardIds = appendUnique(cardIds, card.Id)
+		}
+	}
+	return newMatch(cardIds, ShortLink)
+}
+
+func (matcher *Matcher) matchExactName(description string) Match {
+	var cardIds []string
+	for _, card := range matcher.cardsByName[normalizeName(description)] {
+		cardIds = appendUnique(cardIds, card.Id)
+	}
+	return newMatch(cardIds, ExactName)
+}
+
+func (matcher *Matcher) matchFuzzyName(description string) Match {
+	name := normalizeName(description)
+	if matcher.fuzzyThreshold <= 0 || name == "" {
+		return newMatch(nil, FuzzyName)
+	}
+	bestScore := 0.0
+	var cardIds []string
+	for _, card := range matcher.cards {
+		score := similarity(name, normalizeName(card.Name))
+		if score < matcher.fuzzyThreshold || score < bestScore {
+			continue
+		}
+		if score > bestScore {
+			bestScore = score
+			cardIds = nil
+		}
+		cardIds = appendUnique(cardIds, card.Id)
+	}
+	return newMatch(cardIds, FuzzyName)
+}
+
+func (matcher *Matcher) findCard(reference string) (trello.TrelloCardEntry, bool) {
+	if card, found := matcher.cardsById[reference]; found {
+		return card, true
+	}
+	card, found := matcher.cardsByLink[reference]
+	return card, found
+}
+
+func newMatch(cardIds []string, method MatchMethod) Match {
+	switch len(cardIds) {
+	case 0:
+		return Match{Status: Unmatched, Method: NoMethod}
+	case 1:
+		return Match{Status: Matched, Method: method, CardId: cardIds[0], Candidates: cardIds}
+	default:
+		return Match{Status: Ambiguous, Method: method, Candidates: cardIds}
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// similarity computes the Levenshtein similarity between two strings, from 0 (different) to 1 (equal).
+func similarity(a string, b string) float64 {
+	first := []rune(a)
+	second := []rune(b)
+	maxLength := len(first)
+	if len(second) > maxLength {
+		maxLength = len(second)
+	}
+	if maxLength == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(first, second))/float64(maxLength)
+}
+
+func levenshtein(first []rune, second []rune) int {
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(second)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package linking
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/trello"
+)
+
+var cards = []trello.TrelloCardEntry{
+	{Id: "5f1a2b3c4d5e6f7a8b9c0d1e", Name: "Implement the login page", Short_link: "aBcD1234"},
+	{Id: "5f1a2b3c4d5e6f7a8b9c0d1f", Name: "Design the database schema", Short_link: "eFgH5678"},
+	{Id: "5f1a2b3c4d5e6f7a8b9c0d20", Name: "Code review", Short_link: "iJkL9012"},
+	{Id: "5f1a2b3c4d5e6f7a8b9c0d21", Name: "Code review", Short_link: "mNoP3456"},
+}
+
+func TestMatchCardTag(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("Meeting", []string{"billable", "card:eFgH5678"})
+
+	assert.Equal(t, Match{Status: Matched, Method: CardTag, CardId: "5f1a2b3c4d5e6f7a8b9c0d1f", Candidates: []string{"5f1a2b3c4d5e6f7a8b9c0d1f"}}, match, "Expected a match on the card tag")
+}
+
+func TestMatchCardUrl(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("Fix https://trello.com/c/aBcD1234/12-implement-the-login-page", nil)
+
+	assert.Equal(t, Matched, match.Status, "Expected a match")
+	assert.Equal(t, CardUrl, match.Method, "Expected a match on the card URL")
+	assert.Equal(t, "5f1a2b3c4d5e6f7a8b9c0d1e", match.CardId, "Expected the card ID")
+}
+
+func TestMatchShortLink(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("eFgH5678 migration scripts", nil)
+
+	assert.Equal(t, ShortLink, match.Method, "Expected a match on the card short link")
+	assert.Equal(t, "5f1a2b3c4d5e6f7a8b9c0d1f", match.CardId, "Expected the card ID")
+}
+
+func TestMatchExactName(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("  implement the   Login page", nil)
+
+	assert.Equal(t, ExactName, match.Method, "Expected a match on the card name")
+	assert.Equal(t, "5f1a2b3c4d5e6f7a8b9c0d1e", match.CardId, "Expected the card ID")
+}
+
+func TestMatchAmbiguousExactName(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("Code review", nil)
+
+	assert.Equal(t, Ambiguous, match.Status, "Expected an ambiguous match")
+	assert.Equal(t, []string{"5f1a2b3c4d5e6f7a8b9c0d20", "5f1a2b3c4d5e6f7a8b9c0d21"}, match.Candidates, "Expected the candidate cards")
+}
+
+func TestMatchFuzzyName(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("Implement the logn page", nil)
+
+	assert.Equal(t, FuzzyName, match.Method, "Expected a match on the card name similarity")
+	assert.Equal(t, "5f1a2b3c4d5e6f7a8b9c0d1e", match.CardId, "Expected the card ID")
+}
+
+func TestMatchFuzzyNameBelowThreshold(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("Implement the signup page", nil)
+
+	assert.Equal(t, Unmatched, match.Status, "Expected no match")
+}
+
+func TestMatchFuzzyNameDisabled(t *testing.T) {
+	matcher := NewMatcher(cards, 0)
+
+	match := matcher.Match("Implement the logn page", nil)
+
+	assert.Equal(t, Unmatched, match.Status, "Expected no match")
+}
+
+func TestMatchUnknownCardTagFallsBackToName(t *testing.T) {
+	matcher := NewMatcher(cards, 0.85)
+
+	match := matcher.Match("Design the database schema", []string{"card:unknown"})
+
+	assert.Equal(t, ExactName, match.Method, "Expected a match on the card name")
+	assert.Equal(t, "5f1a2b3c4d5e6f7a8b9c0d1f", match.CardId, "Expected the card ID")
+}
@@ -74,18 +74,31 @@ func (downloadStructAsCsv *DownloadStructAsCsv) DownloadAll(entries []interface{
 
 func retrieveColumnNames(entries []interface{}) []string {
 	fields := reflect.Indirect(reflect.ValueOf(entries[0]))
-	var columnNames = make([]string, fields.Type().NumField())
-	for i := 0; i < fields.Type().NumField(); i++ {
-		columnNames[i] = fields.Type().Field(i).Name
+	fieldIndexes := csvFieldIndexes(fields.Type())
+	var columnNames = make([]string, len(fieldIndexes))
+	for i, fieldIndex := range fieldIndexes {
+		columnNames[i] = fields.Type().Field(fieldIndex).Name
 	}
 	return columnNames
 }
 
+// csvFieldIndexes returns the indexes of the struct fields written in the CSV file. The fields with the tag `csv:"-"` are skipped.
+func csvFieldIndexes(structType reflect.Type) []int {
+	var fieldIndexes []int
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).Tag.Get("csv") == "-" {
+			continue
+		}
+		fieldIndexes = append(fieldIndexes, i)
+	}
+	return fieldIndexes
+}
+
 func (downloadStructAsCsv *DownloadStructAsCsv) retrieveFieldValues(entry interface{}, columnNames []string) []string {
 	var values = make([]string, len(columnNames))
 	fields := reflect.Indirect(reflect.ValueOf(entry))
-	for i := 0; i < len(columnNames); i++ {
-		field := fields.Field(i)
+	for i, fieldIndex := range csvFieldIndexes(fields.Type()) {
+		field := fields.Field(fieldIndex)
 		fieldValue := field.Interface()
 		switch fieldValue := fieldValue.(type) {
 		case string:
@@ -94,12 +107,20 @@ func (downloadStructAsCsv *DownloadStructAsCsv) retrieveFieldValues(entry interf
 			values[i] = strconv.FormatInt(fieldValue, 10)
 		case uint64:
 			values[i] = strconv.FormatUint(fieldValue, 10)
+		case float64:
+			values[i] = strconv.FormatFloat(fieldValue, 'f', -1, 64)
 		case bool:
 			values[i] = strconv.FormatBool(fieldValue)
 		case time.Time:
 			values[i] = fieldValue.Format(time.RFC3339Nano)
 		case []string:
 			values[i] = strings.Join(fieldValue, ",")
+		case []uint64:
+			ids := make([]string, len(fieldValue))
+			for j, id := range fieldValue {
+				ids[j] = strconv.FormatUint(id, 10)
+			}
+			values[i] = strings.Join(ids, ",")
 		default:
 			downloadStructAsCsv.logger.Error("Cannot convert the data type", zap.String("Data type", fmt.Sprintf("%T", fieldValue)))
 		}
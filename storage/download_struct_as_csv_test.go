@@ -15,9 +15,12 @@ type ExampleStruct struct {
 	StringField string
 	Int64Field  int64
 	Uint64Field uint64
+	FloatField  float64
 	BoolField   bool
 	TimeField   time.Time
 	StringArray []string
+	Uint64Array []uint64
+	SkipField   []int `csv:"-"`
 }
 
 func TestDownloadStructAsCsvCreateThrowsErrorOnNilLogger(t *testing.T) {
@@ -47,9 +50,12 @@ func TestDownloadStructAsCsv(t *testing.T) {
 		StringField: "string field value",
 		Int64Field:  75,
 		Uint64Field: 9,
+		FloatField:  2.5,
 		BoolField:   true,
 		TimeField:   time.Date(2021, time.Month(01), 00, 0, 0, 0, 0, time.UTC),
 		StringArray: []string{"string array field 1", "string array field 2"},
+		Uint64Array: []uint64{30, 31},
+		SkipField:   []int{1},
 	}
 	var exampleStructEntries []ExampleStruct
 	exampleStructEntries = append(exampleStructEntries, exampleStructEntry1)
@@ -81,8 +87,8 @@ func TestDownloadStructAsCsv(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error while reading the file %s: %v", fileName, err)
 	}
-	expectedData := `StringField,Int64Field,Uint64Field,BoolField,TimeField,StringArray
-string field value,75,9,true,2020-12-31T00:00:00Z,"string array field 1,string array field 2"
+	expectedData := `StringField,Int64Field,Uint64Field,FloatField,BoolField,TimeField,StringArray,Uint64Array
+string field value,75,9,2.5,true,2020-12-31T00:00:00Z,"string array field 1,string array field 2","30,31"
 `
 	assert.Equal(t, []byte(expectedData), data, "Expected same file content")
 }
@@ -110,6 +110,12 @@ func (insertFromCsv *InsertFromCsv) insertRow(databaseTableName string, columnNa
 				return err
 			}
 			args = append(args, reflect.ValueOf(value))
+		case float64:
+			value, err := strconv.ParseFloat(line[i], 64)
+			if err != nil {
+				return err
+			}
+			args = append(args, reflect.ValueOf(value))
 		case bool:
 			value, err := strconv.ParseBool(line[i])
 			if err != nil {
@@ -125,6 +131,16 @@ func (insertFromCsv *InsertFromCsv) insertRow(databaseTableName string, columnNa
 		case []string:
 			value := pq.Array(strings.Split(line[i], ","))
 			args = append(args, reflect.ValueOf(value))
+		case []uint64:
+			value := pq.Int64Array{}
+			for _, id := range strings.FieldsFunc(line[i], func(r rune) bool { return r == ',' }) {
+				parsedId, err := strconv.ParseInt(id, 10, 64)
+				if err != nil {
+					return err
+				}
+				value = append(value, parsedId)
+			}
+			args = append(args, reflect.ValueOf(value))
 		default:
 			insertFromCsv.logger.Error("Cannot convert the data type", zap.String("Data type", fmt.Sprintf("%T", fieldValue)))
 		}
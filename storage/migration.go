@@ -0,0 +1,353 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLockId defines the key of the PostgreSQL advisory lock that serializes the migrations of concurrent application instances.
+const migrationLockId int64 = 7326173940
+
+// Migration struct defines a versioned change of the database schema.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus struct defines the state of a migration in the database.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified is true when the migration file changed after the migration was applied.
+	Modified bool
+}
+
+// migrationConnection interface defines the database primitives shared by a database connection pool and a single database connection.
+type migrationConnection interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// appliedMigration struct defines a row of the "schema_migrations" table.
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator struct defines the service that applies the embedded migrations to the database.
+type Migrator struct {
+	databaseConnection *sql.DB
+	migrations         []Migration
+}
+
+// InvalidMigrationFileError defines the error for a migration file with an invalid name or without a pair.
+type InvalidMigrationFileError struct {
+	FileName string
+	Message  string
+}
+
+func (err *InvalidMigrationFileError) Error() string {
+	return fmt.Sprintf("Invalid migration file %s: %s.", err.FileName, err.Message)
+}
+
+// ModifiedMigrationError defines the error for a migration file changed after the migration was applied.
+type ModifiedMigrationError struct {
+	Version int
+	Name    string
+}
+
+func (err *ModifiedMigrationError) Error() string {
+	return fmt.Sprintf("The migration %03d_%s was modified after it was applied to the database.", err.Version, err.Name)
+}
+
+// UnknownMigrationError defines the error for a migration applied to the database that doesn't exist in the application.
+type UnknownMigrationError struct {
+	Version int
+	Name    string
+}
+
+func (err *UnknownMigrationError) Error() string {
+	return fmt.Sprintf("The migration %03d_%s applied to the database doesn't exist in the application.", err.Version, err.Name)
+}
+
+// PendingMigrationsError defines the error for a database without the latest migrations applied.
+type PendingMigrationsError struct {
+	Count int
+}
+
+func (err *PendingMigrationsError) Error() string {
+	return fmt.Sprintf("The database has %d pending migrations, run \"migrate up\" to apply them.", err.Count)
+}
+
+// NewMigrator creates a new Migrator for the migrations embedded in the application.
+func NewMigrator(databaseConnection *sql.DB) (*Migrator, error) {
+	return newMigrator(databaseConnection, migrationFiles, "migrations")
+}
+
+func newMigrator(databaseConnection *sql.DB, fileSystem fs.FS, directory string) (*Migrator, error) {
+	if databaseConnection == nil {
+		return nil, &application_errors.NilParameterError{ParameterName: "databaseConnection"}
+	}
+	migrations, err := loadMigrations(fileSystem, directory)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		databaseConnection: databaseConnection,
+		migrations:         migrations,
+	}, nil
+}
+
+// loadMigrations reads the "NNN_name.up.sql" and "NNN_name.down.sql" migration files, sorted by version.
+func loadMigrations(fileSystem fs.FS, directory string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fileSystem, directory)
+	if err != nil {
+		return nil, err
+	}
+	migrationsByVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, &InvalidMigrationFileError{FileName: entry.Name(), Message: "use the format NNN_name.up.sql or NNN_name.down.sql"}
+		}
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fileSystem, directory+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, found := migrationsByVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: matches[2]}
+			migrationsByVersion[version] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, &InvalidMigrationFileError{FileName: entry.Name(), Message: fmt.Sprintf("the version %d is used by multiple migrations", version)}
+		}
+		if matches[3] == "up" {
+			migration.Up = string(content)
+			checksum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(checksum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	var migrations []Migration
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" {
+			return nil, &InvalidMigrationFileError{FileName: fmt.Sprintf("%03d_%s.up.sql", migration.Version, migration.Name), Message: "the file is missing or empty"}
+		}
+		if migration.Down == "" {
+			return nil, &InvalidMigrationFileError{FileName: fmt.Sprintf("%03d_%s.down.sql", migration.Version, migration.Name), Message: "the file is missing or empty"}
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies the pending migrations in order, each one in its own transaction, and returns the applied migrations.
+// The migrations are applied while holding an advisory lock, so concurrent application instances apply them only once.
+func (migrator *Migrator) Up() (applied []Migration, err error) {
+	err = migrator.withLock(func(conn *sql.Conn) error {
+		appliedMigrations, err := migrator.verifiedAppliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrator.migrations {
+			if _, found := appliedMigrations[migration.Version]; found {
+				continue
+			}
+			err = WithTransaction(conn, func(tx *sql.Tx) error {
+				_, err := tx.Exec(migration.Up)
+				if err != nil {
+					return err
+				}
+				_, err = tx.Exec(`INSERT INTO schema_migrations(version, name, checksum) VALUES ($1, $2, $3)`, migration.Version, migration.Name, migration.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return
+}
+
+// Down reverts the last applied migrations, at most the number of steps, and returns the reverted migrations.
+// The migrations are reverted while holding the same advisory lock of Up.
+func (migrator *Migrator) Down(steps int) (reverted []Migration, err error) {
+	err = migrator.withLock(func(conn *sql.Conn) error {
+		appliedMigrations, err := migrator.verifiedAppliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrator.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrator.migrations[i]
+			if _, found := appliedMigrations[migration.Version]; !found {
+				continue
+			}
+			err = WithTransaction(conn, func(tx *sql.Tx) error {
+				_, err := tx.Exec(migration.Down)
+				if err != nil {
+					return err
+				}
+				_, err = tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return
+}
+
+// Verify fails when the database has pending migrations, or when an applied migration was modified or removed.
+func (migrator *Migrator) Verify() error {
+	appliedMigrations, err := migrator.verifiedAppliedMigrations(migrator.databaseConnection)
+	if err != nil {
+		return err
+	}
+	pending := len(migrator.migrations) - len(appliedMigrations)
+	if pending > 0 {
+		return &PendingMigrationsError{Count: pending}
+	}
+	return nil
+}
+
+// withLock runs the function on a single database connection that holds the migration advisory lock.
+func (migrator *Migrator) withLock(run func(conn *sql.Conn) error) (err error) {
+	ctx := context.Background()
+	conn, err := migrator.databaseConnection.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := conn.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockId)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_, sqlerr := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockId)
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	err = run(conn)
+	return
+}
+
+// Status returns the state of all the migrations, sorted by version.
+func (migrator *Migrator) Status() (statuses []MigrationStatus, err error) {
+	appliedMigrations, err := migrator.appliedMigrations(migrator.databaseConnection)
+	if err != nil {
+		return
+	}
+	for _, migration := range migrator.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if applied, found := appliedMigrations[migration.Version]; found {
+			status.Applied = true
+			status.AppliedAt = applied.appliedAt
+			status.Modified = applied.checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return
+}
+
+// verifiedAppliedMigrations returns the applied migrations, and fails when an applied migration was modified or removed.
+func (migrator *Migrator) verifiedAppliedMigrations(conn migrationConnection) (map[int]appliedMigration, error) {
+	appliedMigrations, err := migrator.appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+	migrationsByVersion := make(map[int]Migration)
+	for _, migration := range migrator.migrations {
+		migrationsByVersion[migration.Version] = migration
+	}
+	for version, applied := range appliedMigrations {
+		migration, found := migrationsByVersion[version]
+		if !found {
+			return nil, &UnknownMigrationError{Version: version, Name: applied.name}
+		}
+		if migration.Checksum != applied.checksum {
+			return nil, &ModifiedMigrationError{Version: version, Name: migration.Name}
+		}
+	}
+	return appliedMigrations, nil
+}
+
+// appliedMigrations creates the "schema_migrations" table if it doesn't exist, and reads the applied migrations.
+func (migrator *Migrator) appliedMigrations(conn migrationConnection) (appliedMigrations map[int]appliedMigration, err error) {
+	sqlStmt := `CREATE TABLE IF NOT EXISTS schema_migrations
+				(
+					version         integer NOT NULL,
+					name            varchar(255) NOT NULL,
+					checksum        varchar(64) NOT NULL,
+					applied_at      timestamp NOT NULL DEFAULT now(),
+					PRIMARY KEY(version)
+				);`
+	_, err = conn.ExecContext(context.Background(), sqlStmt)
+	if err != nil {
+		return
+	}
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := rows.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	appliedMigrations = make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var applied appliedMigration
+		err = rows.Scan(&version, &applied.name, &applied.checksum, &applied.appliedAt)
+		if err != nil {
+			return
+		}
+		appliedMigrations[version] = applied
+	}
+	err = rows.Err()
+	return
+}
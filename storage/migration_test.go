@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+)
+
+var testMigrationFiles = fstest.MapFS{
+	"migrations/001_create_example.up.sql":     {Data: []byte("CREATE TABLE example (id integer);")},
+	"migrations/001_create_example.down.sql":   {Data: []byte("DROP TABLE example;")},
+	"migrations/002_add_example_name.up.sql":   {Data: []byte("ALTER TABLE example ADD COLUMN name text;")},
+	"migrations/002_add_example_name.down.sql": {Data: []byte("ALTER TABLE example DROP COLUMN name;")},
+}
+
+func TestMigratorCreateThrowsErrorOnNilDatabaseConnection(t *testing.T) {
+	_, err := NewMigrator(nil)
+	switch err.(type) {
+	case *application_errors.NilParameterError:
+		return
+	default:
+		t.Errorf("Expect a NilParameterError while creating Migrator with nil databaseConnection.")
+	}
+}
+
+func TestMigratorLoadsEmbeddedMigrations(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("Error creating Migrator: %v", err)
+	}
+	assert.Equal(t, 1, migrator.migrations[0].Version, "Expected the first migration")
+	assert.Equal(t, "create_toggl_time_and_trello_card", migrator.migrations[0].Name, "Expected the first migration")
+}
+
+func TestMigratorLoadThrowsInvalidMigrationFileErrorOnMissingDownMigration(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	files := fstest.MapFS{
+		"migrations/001_create_example.up.sql": {Data: []byte("CREATE TABLE example (id integer);")},
+	}
+
+	_, err = newMigrator(db, files, "migrations")
+	switch err.(type) {
+	case *InvalidMigrationFileError:
+		return
+	default:
+		t.Errorf("Expect an InvalidMigrationFileError while creating Migrator without the down migration.")
+	}
+}
+
+func TestMigratorUpAppliesPendingMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	migrator, err := newMigrator(db, testMigrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("Error creating Migrator: %v", err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "create_example", migrator.migrations[0].Checksum, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE example ADD COLUMN name text;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").
+		WithArgs(2, "add_example_name", migrator.migrations[1].Checksum).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Errorf("Error in Migrator Up: %v", err)
+	}
+	assert.Equal(t, 1, len(applied), "Expected one applied migration")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the pending migration to be applied: %v", err)
+	}
+}
+
+func TestMigratorUpThrowsModifiedMigrationErrorOnChangedChecksum(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	migrator, err := newMigrator(db, testMigrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("Error creating Migrator: %v", err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "create_example", "edited", time.Now()))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = migrator.Up()
+	switch err.(type) {
+	case *ModifiedMigrationError:
+		return
+	default:
+		t.Errorf("Expect a ModifiedMigrationError in Migrator Up with an edited migration, got %v", err)
+	}
+}
+
+func TestMigratorDownRevertsLastMigration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	migrator, err := newMigrator(db, testMigrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("Error creating Migrator: %v", err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "create_example", migrator.migrations[0].Checksum, time.Now()).
+			AddRow(2, "add_example_name", migrator.migrations[1].Checksum, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE example DROP COLUMN name;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := migrator.Down(1)
+	if err != nil {
+		t.Errorf("Error in Migrator Down: %v", err)
+	}
+	assert.Equal(t, 1, len(reverted), "Expected one reverted migration")
+	assert.Equal(t, 2, reverted[0].Version, "Expected the last migration to be reverted")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the last migration to be reverted: %v", err)
+	}
+}
+
+func TestMigratorVerifyThrowsPendingMigrationsError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	migrator, err := newMigrator(db, testMigrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("Error creating Migrator: %v", err)
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "create_example", migrator.migrations[0].Checksum, time.Now()))
+
+	err = migrator.Verify()
+	assert.Equal(t, &PendingMigrationsError{Count: 1}, err, "Expected the pending migration error")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect no migration to be applied: %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS trello_card;
+
+DROP TABLE IF EXISTS toggl_time;
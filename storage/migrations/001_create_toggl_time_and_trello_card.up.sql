@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS toggl_time
+(
+    id              varchar(255) NOT NULL,
+    description     text NOT NULL,
+    start           timestamp NOT NULL,
+    stop            timestamp NOT NULL,
+    duration        integer NOT NULL,
+    billable        boolean NOT NULL,
+    workspace_id    integer NOT NULL,
+    project_id      integer NOT NULL,
+    project_name    varchar(255) NOT NULL,
+    tags            varchar(255)[] NOT NULL DEFAULT array[]::varchar(255)[],
+    trello_card_id  varchar(255) NOT NULL DEFAULT '',
+    PRIMARY KEY(id)
+);
+
+CREATE TABLE IF NOT EXISTS trello_card
+(
+    id              varchar(255) NOT NULL,
+    name            varchar(255) NOT NULL,
+    closed          boolean NOT NULL,
+    labels          varchar(255)[] NOT NULL DEFAULT array[]::varchar(255)[],
+    project         varchar(255) NOT NULL DEFAULT '',
+    customer        varchar(255) NOT NULL DEFAULT '',
+    team            varchar(255) NOT NULL DEFAULT '',
+    type            varchar(255) NOT NULL DEFAULT '',
+    PRIMARY KEY(id)
+);
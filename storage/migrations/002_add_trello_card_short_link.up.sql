@@ -0,0 +1 @@
+ALTER TABLE trello_card ADD COLUMN IF NOT EXISTS short_link varchar(255) NOT NULL DEFAULT '';
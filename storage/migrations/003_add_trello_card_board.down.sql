@@ -0,0 +1,3 @@
+ALTER TABLE trello_card DROP COLUMN IF EXISTS board_name;
+
+ALTER TABLE trello_card DROP COLUMN IF EXISTS board_id;
@@ -0,0 +1,3 @@
+ALTER TABLE trello_card ADD COLUMN IF NOT EXISTS board_id varchar(255) NOT NULL DEFAULT '';
+
+ALTER TABLE trello_card ADD COLUMN IF NOT EXISTS board_name varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+ALTER TABLE trello_card DROP COLUMN IF EXISTS status;
+
+ALTER TABLE trello_card DROP COLUMN IF EXISTS list_name;
+
+ALTER TABLE trello_card DROP COLUMN IF EXISTS list_id;
@@ -0,0 +1,5 @@
+ALTER TABLE trello_card ADD COLUMN IF NOT EXISTS list_id varchar(255) NOT NULL DEFAULT '';
+
+ALTER TABLE trello_card ADD COLUMN IF NOT EXISTS list_name varchar(255) NOT NULL DEFAULT '';
+
+ALTER TABLE trello_card ADD COLUMN IF NOT EXISTS status varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS trello_card_cycle_time;
+
+DROP TABLE IF EXISTS trello_card_transition;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS trello_card_transition
+(
+    id              varchar(255) NOT NULL,
+    card_id         varchar(255) NOT NULL,
+    board_id        varchar(255) NOT NULL DEFAULT '',
+    action_type     varchar(255) NOT NULL,
+    from_list_id    varchar(255) NOT NULL DEFAULT '',
+    from_list_name  varchar(255) NOT NULL DEFAULT '',
+    to_list_id      varchar(255) NOT NULL DEFAULT '',
+    to_list_name    varchar(255) NOT NULL DEFAULT '',
+    to_status       varchar(255) NOT NULL DEFAULT '',
+    date            timestamp NOT NULL,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS trello_card_transition_card_id_idx ON trello_card_transition (card_id);
+
+-- The lead time (created -> done) and the cycle time (first in progress -> done) in seconds of the cards in a done list.
+CREATE OR REPLACE VIEW trello_card_cycle_time AS
+SELECT
+    trello_card.id AS card_id,
+    trello_card.name,
+    trello_card.board_name,
+    trello_card.customer,
+    trello_card.type,
+    transition.created_at,
+    transition.started_at,
+    transition.done_at,
+    EXTRACT(EPOCH FROM transition.done_at - transition.created_at)::bigint AS lead_time,
+    EXTRACT(EPOCH FROM transition.done_at - transition.started_at)::bigint AS cycle_time
+FROM trello_card
+JOIN (
+    SELECT
+        card_id,
+        min(date) FILTER (WHERE action_type = 'createCard') AS created_at,
+        min(date) FILTER (WHERE to_status = 'in-progress') AS started_at,
+        max(date) FILTER (WHERE to_status = 'done') AS done_at
+    FROM trello_card_transition
+    GROUP BY card_id
+) AS transition ON transition.card_id = trello_card.id
+WHERE trello_card.status = 'done' AND transition.done_at IS NOT NULL;
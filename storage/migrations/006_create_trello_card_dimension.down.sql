@@ -0,0 +1 @@
+DROP TABLE IF EXISTS trello_card_dimension;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS trello_card_dimension
+(
+    card_id         varchar(255) NOT NULL,
+    name            varchar(255) NOT NULL,
+    value           varchar(255) NOT NULL,
+    PRIMARY KEY(card_id, name, value)
+);
+
+CREATE INDEX IF NOT EXISTS trello_card_dimension_name_value_idx ON trello_card_dimension (name, value);
+
+INSERT INTO trello_card_dimension (card_id, name, value)
+SELECT id, 'project', project FROM trello_card WHERE project <> ''
+UNION ALL
+SELECT id, 'customer', customer FROM trello_card WHERE customer <> ''
+UNION ALL
+SELECT id, 'team', team FROM trello_card WHERE team <> ''
+UNION ALL
+SELECT id, 'type', type FROM trello_card WHERE type <> ''
+ON CONFLICT DO NOTHING;
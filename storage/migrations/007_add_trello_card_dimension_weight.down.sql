@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS toggl_time_dimension;
+
+ALTER TABLE trello_card_dimension DROP COLUMN IF EXISTS weight;
@@ -0,0 +1,13 @@
+ALTER TABLE trello_card_dimension ADD COLUMN IF NOT EXISTS weight double precision NOT NULL DEFAULT 1;
+
+-- The Toggl time entries per Trello card dimension value, with the duration split by the weight of the value.
+CREATE OR REPLACE VIEW toggl_time_dimension AS
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration * trello_card_dimension.weight AS duration,
+    toggl_time.trello_card_id,
+    trello_card_dimension.name AS dimension,
+    trello_card_dimension.value AS dimension_value
+FROM toggl_time
+JOIN trello_card_dimension ON trello_card_dimension.card_id = toggl_time.trello_card_id;
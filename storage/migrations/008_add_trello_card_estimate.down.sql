@@ -0,0 +1 @@
+ALTER TABLE trello_card DROP COLUMN IF EXISTS estimate;
@@ -0,0 +1 @@
+ALTER TABLE trello_card ADD COLUMN IF NOT EXISTS estimate double precision NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+DROP VIEW IF EXISTS trello_card_estimation;
@@ -0,0 +1,17 @@
+-- The estimate and the tracked time in seconds of the estimated Trello cards.
+CREATE OR REPLACE VIEW trello_card_estimation AS
+SELECT
+    trello_card.id AS card_id,
+    trello_card.name,
+    trello_card.board_name,
+    trello_card.customer,
+    trello_card.team,
+    trello_card.type,
+    trello_card.status,
+    trello_card.estimate,
+    COALESCE(sum(toggl_time.duration), 0)::bigint AS tracked_time,
+    max(toggl_time.stop) AS last_tracked_at
+FROM trello_card
+LEFT JOIN toggl_time ON toggl_time.trello_card_id = trello_card.id
+WHERE trello_card.estimate > 0
+GROUP BY trello_card.id;
@@ -0,0 +1,3 @@
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS user_name;
+
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS client_name;
@@ -0,0 +1,3 @@
+ALTER TABLE toggl_time ADD COLUMN IF NOT EXISTS client_name varchar(255) NOT NULL DEFAULT '';
+
+ALTER TABLE toggl_time ADD COLUMN IF NOT EXISTS user_name varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+DROP VIEW IF EXISTS toggl_time_dimension;
+
+CREATE VIEW toggl_time_dimension AS
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration * trello_card_dimension.weight AS duration,
+    toggl_time.trello_card_id,
+    trello_card_dimension.name AS dimension,
+    trello_card_dimension.value AS dimension_value
+FROM toggl_time
+JOIN trello_card_dimension ON trello_card_dimension.card_id = toggl_time.trello_card_id;
+
+DROP INDEX IF EXISTS toggl_time_user_name_idx;
+
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS user_id;
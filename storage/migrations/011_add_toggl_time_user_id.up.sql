@@ -0,0 +1,17 @@
+ALTER TABLE toggl_time ADD COLUMN IF NOT EXISTS user_id bigint NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS toggl_time_user_name_idx ON toggl_time (user_name);
+
+-- The Toggl time entries per Trello card dimension value, with the user of the time entry.
+CREATE OR REPLACE VIEW toggl_time_dimension AS
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration * trello_card_dimension.weight AS duration,
+    toggl_time.trello_card_id,
+    trello_card_dimension.name AS dimension,
+    trello_card_dimension.value AS dimension_value,
+    toggl_time.user_id,
+    toggl_time.user_name
+FROM toggl_time
+JOIN trello_card_dimension ON trello_card_dimension.card_id = toggl_time.trello_card_id;
@@ -0,0 +1,17 @@
+DROP VIEW IF EXISTS toggl_time_dimension;
+
+CREATE VIEW toggl_time_dimension AS
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration * trello_card_dimension.weight AS duration,
+    toggl_time.trello_card_id,
+    trello_card_dimension.name AS dimension,
+    trello_card_dimension.value AS dimension_value,
+    toggl_time.user_id,
+    toggl_time.user_name
+FROM toggl_time
+JOIN trello_card_dimension ON trello_card_dimension.card_id = toggl_time.trello_card_id;
+
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS client_id;
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS workspace_name;
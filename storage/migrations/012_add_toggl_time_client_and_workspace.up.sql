@@ -0,0 +1,33 @@
+ALTER TABLE toggl_time ADD COLUMN IF NOT EXISTS workspace_name varchar(255) NOT NULL DEFAULT '';
+ALTER TABLE toggl_time ADD COLUMN IF NOT EXISTS client_id bigint NOT NULL DEFAULT 0;
+
+-- The Toggl time entries per Trello card dimension value, with the user of the time entry.
+-- The Toggl client is the customer of the time entries whose Trello card has no customer, e.g. the time entries never linked to a Trello card.
+CREATE OR REPLACE VIEW toggl_time_dimension AS
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration * trello_card_dimension.weight AS duration,
+    toggl_time.trello_card_id,
+    trello_card_dimension.name AS dimension,
+    trello_card_dimension.value AS dimension_value,
+    toggl_time.user_id,
+    toggl_time.user_name
+FROM toggl_time
+JOIN trello_card_dimension ON trello_card_dimension.card_id = toggl_time.trello_card_id
+UNION ALL
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration::double precision AS duration,
+    toggl_time.trello_card_id,
+    'customer' AS dimension,
+    toggl_time.client_name AS dimension_value,
+    toggl_time.user_id,
+    toggl_time.user_name
+FROM toggl_time
+WHERE toggl_time.client_name != ''
+AND NOT EXISTS (
+    SELECT 1 FROM trello_card_dimension
+    WHERE trello_card_dimension.card_id = toggl_time.trello_card_id AND trello_card_dimension.name = 'customer'
+);
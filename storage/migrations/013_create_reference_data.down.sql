@@ -0,0 +1,43 @@
+DROP VIEW IF EXISTS toggl_time_dimension;
+
+-- The Toggl time entries per Trello card dimension value, with the user of the time entry.
+-- The Toggl client is the customer of the time entries whose Trello card has no customer, e.g. the time entries never linked to a Trello card.
+CREATE VIEW toggl_time_dimension AS
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration * trello_card_dimension.weight AS duration,
+    toggl_time.trello_card_id,
+    trello_card_dimension.name AS dimension,
+    trello_card_dimension.value AS dimension_value,
+    toggl_time.user_id,
+    toggl_time.user_name
+FROM toggl_time
+JOIN trello_card_dimension ON trello_card_dimension.card_id = toggl_time.trello_card_id
+UNION ALL
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration::double precision AS duration,
+    toggl_time.trello_card_id,
+    'customer' AS dimension,
+    toggl_time.client_name AS dimension_value,
+    toggl_time.user_id,
+    toggl_time.user_name
+FROM toggl_time
+WHERE toggl_time.client_name != ''
+AND NOT EXISTS (
+    SELECT 1 FROM trello_card_dimension
+    WHERE trello_card_dimension.card_id = toggl_time.trello_card_id AND trello_card_dimension.name = 'customer'
+);
+
+DROP VIEW IF EXISTS trello_card_label;
+DROP VIEW IF EXISTS toggl_time_entry;
+
+ALTER TABLE trello_card DROP COLUMN IF EXISTS label_ids;
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS tag_ids;
+
+DROP TABLE IF EXISTS trello_label;
+DROP TABLE IF EXISTS toggl_tag;
+DROP TABLE IF EXISTS toggl_client;
+DROP TABLE IF EXISTS toggl_project;
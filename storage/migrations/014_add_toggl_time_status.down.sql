@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS toggl_time_status_idx;
+
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS status;
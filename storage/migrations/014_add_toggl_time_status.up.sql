@@ -0,0 +1,6 @@
+ALTER TABLE toggl_time ADD COLUMN IF NOT EXISTS status varchar(255) NOT NULL DEFAULT 'completed';
+
+-- The running time entries stored before the status column have a negative duration, and are reconciled by the next sync.
+UPDATE toggl_time SET status = 'running', duration = 0 WHERE duration < 0;
+
+CREATE INDEX IF NOT EXISTS toggl_time_status_idx ON toggl_time (status) WHERE status = 'running';
@@ -0,0 +1,79 @@
+-- The Toggl time entries with the current names of the project, the client and the tags, joined by ID.
+-- The names stored with the time entry are used when the reference data has not been synced.
+CREATE OR REPLACE VIEW toggl_time_entry AS
+SELECT
+    toggl_time.id,
+    toggl_time.description,
+    toggl_time.start,
+    toggl_time.stop,
+    toggl_time.duration,
+    toggl_time.billable,
+    toggl_time.workspace_id,
+    toggl_time.workspace_name,
+    toggl_time.project_id,
+    COALESCE(toggl_project.name, toggl_time.project_name) AS project_name,
+    COALESCE(toggl_project.client_id, toggl_time.client_id) AS client_id,
+    COALESCE(toggl_client.name, toggl_time.client_name) AS client_name,
+    toggl_time.user_id,
+    toggl_time.user_name,
+    CASE WHEN cardinality(toggl_time.tag_ids) = 0 THEN toggl_time.tags ELSE ARRAY(
+        SELECT toggl_tag.name FROM unnest(toggl_time.tag_ids) WITH ORDINALITY AS tag(id, ordinal)
+        JOIN toggl_tag ON toggl_tag.id = tag.id
+        ORDER BY tag.ordinal
+    ) END AS tags,
+    toggl_time.tag_ids,
+    toggl_time.trello_card_id
+FROM toggl_time
+LEFT JOIN toggl_project ON toggl_project.id = toggl_time.project_id
+LEFT JOIN toggl_client ON toggl_client.id = COALESCE(toggl_project.client_id, toggl_time.client_id);
+
+-- The Toggl time entries per Trello card dimension value, with the user of the time entry.
+-- The Toggl client is the customer of the time entries whose Trello card has no customer, e.g. the time entries never linked to a Trello card.
+CREATE OR REPLACE VIEW toggl_time_dimension AS
+SELECT
+    toggl_time.id,
+    toggl_time.start,
+    toggl_time.duration * trello_card_dimension.weight AS duration,
+    toggl_time.trello_card_id,
+    trello_card_dimension.name AS dimension,
+    trello_card_dimension.value AS dimension_value,
+    toggl_time.user_id,
+    toggl_time.user_name
+FROM toggl_time
+JOIN trello_card_dimension ON trello_card_dimension.card_id = toggl_time.trello_card_id
+UNION ALL
+SELECT
+    toggl_time_entry.id,
+    toggl_time_entry.start,
+    toggl_time_entry.duration::double precision AS duration,
+    toggl_time_entry.trello_card_id,
+    'customer' AS dimension,
+    toggl_time_entry.client_name AS dimension_value,
+    toggl_time_entry.user_id,
+    toggl_time_entry.user_name
+FROM toggl_time_entry
+WHERE toggl_time_entry.client_name != ''
+AND NOT EXISTS (
+    SELECT 1 FROM trello_card_dimension
+    WHERE trello_card_dimension.card_id = toggl_time_entry.trello_card_id AND trello_card_dimension.name = 'customer'
+);
+
+-- The estimate and the tracked time in seconds of the estimated Trello cards.
+CREATE OR REPLACE VIEW trello_card_estimation AS
+SELECT
+    trello_card.id AS card_id,
+    trello_card.name,
+    trello_card.board_name,
+    trello_card.customer,
+    trello_card.team,
+    trello_card.type,
+    trello_card.status,
+    trello_card.estimate,
+    COALESCE(sum(toggl_time.duration), 0)::bigint AS tracked_time,
+    max(toggl_time.stop) AS last_tracked_at
+FROM trello_card
+LEFT JOIN toggl_time ON toggl_time.trello_card_id = trello_card.id
+WHERE trello_card.estimate > 0
+GROUP BY trello_card.id;
+
+ALTER TABLE toggl_time DROP COLUMN IF EXISTS deleted_at;
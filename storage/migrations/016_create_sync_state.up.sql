@@ -0,0 +1,8 @@
+-- The time of the last successful sync of each source, e.g. a Toggl workspace or a Trello board, used by the incremental sync.
+CREATE TABLE IF NOT EXISTS sync_state
+(
+    source          varchar(255) NOT NULL,
+    source_id       varchar(255) NOT NULL,
+    synced_at       timestamp NOT NULL,
+    PRIMARY KEY(source, source_id)
+);
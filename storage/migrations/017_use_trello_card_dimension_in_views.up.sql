@@ -0,0 +1,63 @@
+-- The views list all the dimension values of the cards with multiple values, separated by commas, instead of the first value stored in the table trello_card.
+DROP VIEW IF EXISTS trello_card_cycle_time;
+DROP VIEW IF EXISTS trello_card_estimation;
+
+-- The lead time (created -> done) and the cycle time (first in progress -> done) in seconds of the cards in a done list.
+CREATE VIEW trello_card_cycle_time AS
+SELECT
+    trello_card.id AS card_id,
+    trello_card.name,
+    trello_card.board_name,
+    COALESCE(dimension.customer, '') AS customer,
+    COALESCE(dimension.type, '') AS type,
+    transition.created_at,
+    transition.started_at,
+    transition.done_at,
+    EXTRACT(EPOCH FROM transition.done_at - transition.created_at)::bigint AS lead_time,
+    EXTRACT(EPOCH FROM transition.done_at - transition.started_at)::bigint AS cycle_time
+FROM trello_card
+JOIN (
+    SELECT
+        card_id,
+        min(date) FILTER (WHERE action_type = 'createCard') AS created_at,
+        min(date) FILTER (WHERE to_status = 'in-progress') AS started_at,
+        max(date) FILTER (WHERE to_status = 'done') AS done_at
+    FROM trello_card_transition
+    GROUP BY card_id
+) AS transition ON transition.card_id = trello_card.id
+LEFT JOIN (
+    SELECT
+        card_id,
+        string_agg(value, ', ' ORDER BY value) FILTER (WHERE name = 'customer') AS customer,
+        string_agg(value, ', ' ORDER BY value) FILTER (WHERE name = 'type') AS type
+    FROM trello_card_dimension
+    GROUP BY card_id
+) AS dimension ON dimension.card_id = trello_card.id
+WHERE trello_card.status = 'done' AND transition.done_at IS NOT NULL;
+
+-- The estimate and the tracked time in seconds of the estimated Trello cards.
+CREATE VIEW trello_card_estimation AS
+SELECT
+    trello_card.id AS card_id,
+    trello_card.name,
+    trello_card.board_name,
+    COALESCE(dimension.customer, '') AS customer,
+    COALESCE(dimension.team, '') AS team,
+    COALESCE(dimension.type, '') AS type,
+    trello_card.status,
+    trello_card.estimate,
+    COALESCE(sum(toggl_time.duration), 0)::bigint AS tracked_time,
+    max(toggl_time.stop) AS last_tracked_at
+FROM trello_card
+LEFT JOIN (
+    SELECT
+        card_id,
+        string_agg(value, ', ' ORDER BY value) FILTER (WHERE name = 'customer') AS customer,
+        string_agg(value, ', ' ORDER BY value) FILTER (WHERE name = 'team') AS team,
+        string_agg(value, ', ' ORDER BY value) FILTER (WHERE name = 'type') AS type
+    FROM trello_card_dimension
+    GROUP BY card_id
+) AS dimension ON dimension.card_id = trello_card.id
+LEFT JOIN toggl_time ON toggl_time.trello_card_id = trello_card.id AND toggl_time.deleted_at IS NULL
+WHERE trello_card.estimate > 0
+GROUP BY trello_card.id, dimension.customer, dimension.team, dimension.type;
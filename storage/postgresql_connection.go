@@ -31,13 +31,17 @@ func NewPostgresConnection(dbConfiguration configuration.DBConfiguration) (postg
 	return
 }
 
-// InitDB creates the "toggl_time" and "trello_card" tables if these don't exist.
-func (pc PostgresqlConnection) InitDatabase() error {
-	err := pc.createTogglTimeTable()
+// InitDatabase applies the pending schema migrations when autoMigrate is true, otherwise it verifies that no migration is pending.
+func (pc PostgresqlConnection) InitDatabase(autoMigrate bool) error {
+	migrator, err := NewMigrator(pc.Db)
 	if err != nil {
 		return err
 	}
-	return pc.createTrelloCardTable()
+	if !autoMigrate {
+		return migrator.Verify()
+	}
+	_, err = migrator.Up()
+	return err
 }
 
 // Close closes the PostgreSQL connection.
@@ -49,76 +53,3 @@ func (pc PostgresqlConnection) Close() error {
 func (pc PostgresqlConnection) GetDb() *sql.DB {
 	return pc.Db
 }
-
-func (pc PostgresqlConnection) createTogglTimeTable() (err error) {
-	tx, err := pc.Db.Begin()
-	if err != nil {
-		return
-	}
-	defer func() {
-		switch err {
-		case nil:
-			sqlerr := tx.Commit()
-			if err == nil {
-				err = sqlerr
-			}
-		default:
-			sqlerr := tx.Rollback()
-			if err == nil {
-				err = sqlerr
-			}
-		}
-	}()
-	sqlStmt := `CREATE TABLE IF NOT EXISTS toggl_time
-				(
-					id              varchar(255) NOT NULL,
-					description     text NOT NULL,
-					start           timestamp NOT NULL,
-					stop            timestamp NOT NULL,
-					duration        integer NOT NULL,
-					billable        boolean NOT NULL,
-					workspace_id    integer NOT NULL,
-					project_id      integer NOT NULL,
-					project_name    varchar(255) NOT NULL,
-					tags            varchar(255)[] NOT NULL DEFAULT array[]::varchar(255)[],
-					trello_card_id  varchar(255) NOT NULL DEFAULT '',
-					PRIMARY KEY(id)
-				);`
-	_, err = pc.Db.Exec(sqlStmt)
-	return
-}
-
-func (pc PostgresqlConnection) createTrelloCardTable() (err error) {
-	tx, err := pc.Db.Begin()
-	if err != nil {
-		return
-	}
-	defer func() {
-		switch err {
-		case nil:
-			sqlerr := tx.Commit()
-			if err == nil {
-				err = sqlerr
-			}
-		default:
-			sqlerr := tx.Rollback()
-			if err == nil {
-				err = sqlerr
-			}
-		}
-	}()
-	sqlStmt := `CREATE TABLE IF NOT EXISTS trello_card
-				(
-					id              varchar(255) NOT NULL,
-					name            varchar(255) NOT NULL,
-					closed          boolean NOT NULL,
-					labels          varchar(255)[] NOT NULL DEFAULT array[]::varchar(255)[],
-					project         varchar(255) NOT NULL DEFAULT '',
-					customer        varchar(255) NOT NULL DEFAULT '',
-					team            varchar(255) NOT NULL DEFAULT '',
-					type            varchar(255) NOT NULL DEFAULT '',
-					PRIMARY KEY(id)
-				);`
-	_, err = pc.Db.Exec(sqlStmt)
-	return
-}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Replace struct defines the replacement of the rows that belong to a set of parent keys, e.g. the dimensions of a Trello card.
+type Replace struct {
+	tableName string
+	keyColumn string
+	columns   []string
+	batchSize int
+}
+
+// NewReplace creates a new Replace for the columns of a database table. The keyColumn, which references the parent row, must be the first column.
+func NewReplace(tableName string, columns []string) *Replace {
+	batchSize := defaultBatchSize
+	if batchSize*len(columns) > maxParameters {
+		batchSize = maxParameters / len(columns)
+	}
+	return &Replace{
+		tableName: tableName,
+		keyColumn: columns[0],
+		columns:   columns,
+		batchSize: batchSize,
+	}
+}
+
+// Execute deletes the rows of the parent keys, and inserts the new rows in batches of multiple rows. The duplicated rows are ignored.
+// Run Execute in a transaction in order to replace either all the rows or none of them.
+func (replace *Replace) Execute(executor Executor, keys []string, rows [][]interface{}) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := executor.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s = ANY($1)`, replace.tableName, replace.keyColumn), pq.Array(keys))
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(rows); start += replace.batchSize {
+		end := start + replace.batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		var values []interface{}
+		for _, row := range rows[start:end] {
+			values = append(values, row...)
+		}
+		_, err = executor.Exec(replace.statement(end-start), values...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statement creates the INSERT ... ON CONFLICT DO NOTHING statement for a number of rows.
+func (replace *Replace) statement(rowCount int) string {
+	var rowsPlaceholders = make([]string, rowCount)
+	for row := 0; row < rowCount; row++ {
+		var placeholders = make([]string, len(replace.columns))
+		for i := range replace.columns {
+			placeholders[i] = fmt.Sprintf("$%d", row*len(replace.columns)+i+1)
+		}
+		rowsPlaceholders[row] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+	return fmt.Sprintf(`INSERT INTO %s(%s) VALUES %s ON CONFLICT DO NOTHING`,
+		replace.tableName, strings.Join(replace.columns, ", "), strings.Join(rowsPlaceholders, ", "))
+}
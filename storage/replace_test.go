@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/lib/pq"
+)
+
+func TestReplaceStatementWithMultipleRows(t *testing.T) {
+	replace := NewReplace("example", []string{"parent_id", "name", "value"})
+
+	expectedStatement := `INSERT INTO example(parent_id, name, value) VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT DO NOTHING`
+	assert.Equal(t, expectedStatement, replace.statement(2), "Expected the replace statement")
+}
+
+func TestReplaceExecuteDeletesAndInsertsInBatches(t *testing.T) {
+	replace := NewReplace("example", []string{"parent_id", "name"})
+	replace.batchSize = 2
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM example WHERE parent_id = ANY($1)")).
+		WithArgs(pq.Array([]string{"1", "2"})).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO example").
+		WithArgs("1", "first", "1", "second").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO example").
+		WithArgs("2", "third").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = replace.Execute(db, []string{"1", "2"}, [][]interface{}{{"1", "first"}, {"1", "second"}, {"2", "third"}})
+	if err != nil {
+		t.Errorf("Error in Replace Execute: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the delete and two insert batches: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SyncOverlap defines how far back from the last sync time the incremental sync starts, so that the clock differences
+// between the application and the APIs don't skip any modification. The overlapping entries are stored again unchanged.
+const SyncOverlap = time.Minute
+
+// GetSyncState retrieves the time of the last successful sync of the source, e.g. "toggl_workspace", by source ID.
+func GetSyncState(executor Executor, source string) (syncState map[string]time.Time, err error) {
+	rows, err := executor.Query(`SELECT source_id, synced_at FROM sync_state WHERE source = $1`, source)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := rows.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	syncState = make(map[string]time.Time)
+	for rows.Next() {
+		var sourceId string
+		var syncedAt time.Time
+		err = rows.Scan(&sourceId, &syncedAt)
+		if err != nil {
+			return
+		}
+		syncState[sourceId] = syncedAt.UTC()
+	}
+	return syncState, rows.Err()
+}
+
+// SaveSyncState records the syncedAt time as the last successful sync of the source IDs.
+func SaveSyncState(executor Executor, source string, sourceIds []string, syncedAt time.Time) error {
+	return saveSyncState(executor, source, sourceIds, syncedAt, `DO UPDATE SET synced_at = EXCLUDED.synced_at`)
+}
+
+// InitSyncState records the syncedAt time as the last successful sync of the source IDs that have never been synced.
+func InitSyncState(executor Executor, source string, sourceIds []string, syncedAt time.Time) error {
+	return saveSyncState(executor, source, sourceIds, syncedAt, `DO NOTHING`)
+}
+
+func saveSyncState(executor Executor, source string, sourceIds []string, syncedAt time.Time, conflictAction string) error {
+	if len(sourceIds) == 0 {
+		return nil
+	}
+	_, err := executor.Exec(`INSERT INTO sync_state (source, source_id, synced_at) SELECT $1, unnest($2::varchar[]), $3
+		ON CONFLICT (source, source_id) `+conflictAction, source, pq.Array(sourceIds), syncedAt.UTC())
+	return err
+}
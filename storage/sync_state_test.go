@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/lib/pq"
+)
+
+func TestGetSyncState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	syncedAt := time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT source_id, synced_at FROM sync_state WHERE source").
+		WithArgs("toggl_workspace").
+		WillReturnRows(sqlmock.NewRows([]string{"source_id", "synced_at"}).AddRow("2245503", syncedAt))
+
+	syncState, err := GetSyncState(db, "toggl_workspace")
+	if err != nil {
+		t.Fatalf("Error in GetSyncState: %v", err)
+	}
+	assert.Equal(t, map[string]time.Time{"2245503": syncedAt}, syncState, "Expected the sync time by workspace ID")
+}
+
+func TestSaveSyncStateUpdatesTheSyncTime(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	syncedAt := time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO sync_state .* DO UPDATE SET synced_at").
+		WithArgs("trello_board", pq.Array([]string{"board1", "board2"}), syncedAt).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = SaveSyncState(db, "trello_board", []string{"board1", "board2"}, syncedAt)
+	if err != nil {
+		t.Errorf("Error in SaveSyncState: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the sync time of the boards to be saved: %v", err)
+	}
+}
+
+func TestInitSyncStateKeepsTheSyncTime(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	syncedAt := time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO sync_state .* DO NOTHING").
+		WithArgs("toggl_workspace", pq.Array([]string{"2245503"}), syncedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = InitSyncState(db, "toggl_workspace", []string{"2245503"}, syncedAt)
+	if err != nil {
+		t.Errorf("Error in InitSyncState: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the sync time of the workspace to be initialized: %v", err)
+	}
+}
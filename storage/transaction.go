@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TxBeginner interface defines the primitive shared by a database connection pool and a single database connection to begin a transaction.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// WithTransaction runs the function in a database transaction.
+// The transaction is committed when the function succeeds, and rolled back when the function returns an error.
+func WithTransaction(databaseConnection TxBeginner, run func(tx *sql.Tx) error) (err error) {
+	tx, err := databaseConnection.BeginTx(context.Background(), nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		switch err {
+		case nil:
+			sqlerr := tx.Commit()
+			if err == nil {
+				err = sqlerr
+			}
+		default:
+			sqlerr := tx.Rollback()
+			if err == nil {
+				err = sqlerr
+			}
+		}
+	}()
+	err = run(tx)
+	return
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Executor interface defines the database primitives shared by a database connection and a transaction.
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// maxParameters defines the maximum number of parameters in a PostgreSQL statement.
+const maxParameters = 65535
+
+// defaultBatchSize defines the maximum number of rows written by a single statement.
+const defaultBatchSize = 500
+
+// Upsert struct defines the insert or update of the entries of a database table by primary key.
+type Upsert struct {
+	tableName        string
+	keyColumn        string
+	columns          []string
+	preservedColumns []string
+	batchSize        int
+}
+
+// SyncReport struct defines the number of rows inserted, updated or unchanged by a sync.
+type SyncReport struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+// UnknownColumnError defines the unknown database table column error.
+type UnknownColumnError struct {
+	TableName  string
+	ColumnName string
+}
+
+func (err *UnknownColumnError) Error() string {
+	return fmt.Sprintf("The column %s does not exist in the database table %s.", err.ColumnName, err.TableName)
+}
+
+// NewUpsert creates a new Upsert for the columns of a database table.
+// The keyColumn must be the first column. The preservedColumns are written when a row is inserted, and left unchanged when a row is updated.
+func NewUpsert(tableName string, columns []string, preservedColumns []string) (*Upsert, error) {
+	for _, preservedColumn := range preservedColumns {
+		if SliceIndex(len(columns), func(i int) bool { return columns[i] == preservedColumn }) == -1 {
+			return nil, &UnknownColumnError{TableName: tableName, ColumnName: preservedColumn}
+		}
+	}
+	batchSize := defaultBatchSize
+	if batchSize*len(columns) > maxParameters {
+		batchSize = maxParameters / len(columns)
+	}
+	return &Upsert{
+		tableName:        tableName,
+		keyColumn:        columns[0],
+		columns:          columns,
+		preservedColumns: preservedColumns,
+		batchSize:        batchSize,
+	}, nil
+}
+
+// Execute inserts or updates the rows with the values of the columns in batches of multiple rows, and counts the result in the report.
+// When multiple rows have the same key, only the last row is written.
+// Run Execute in a transaction in order to write either all the rows or none of them.
+func (upsert *Upsert) Execute(executor Executor, report *SyncReport, rows [][]interface{}) error {
+	rows = uniqueRows(rows)
+	for start := 0; start < len(rows); start += upsert.batchSize {
+		end := start + upsert.batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		err := upsert.executeBatch(executor, report, rows[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (upsert *Upsert) executeBatch(executor Executor, report *SyncReport, rows [][]interface{}) (err error) {
+	var values []interface{}
+	for _, row := range rows {
+		values = append(values, row...)
+	}
+	result, err := executor.Query(upsert.statement(len(rows)), values...)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := result.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	changed := 0
+	for result.Next() {
+		var inserted bool
+		err = result.Scan(&inserted)
+		if err != nil {
+			return
+		}
+		if inserted {
+			report.Inserted++
+		} else {
+			report.Updated++
+		}
+		changed++
+	}
+	report.Unchanged += len(rows) - changed
+	return result.Err()
+}
+
+// statement creates the INSERT ... ON CONFLICT DO UPDATE statement for a number of rows.
+// A row is updated only when at least one of the updated columns changes, otherwise the row is not returned.
+// The returned "inserted" column distinguishes the inserted rows from the updated rows.
+func (upsert *Upsert) statement(rowCount int) string {
+	var rowsPlaceholders = make([]string, rowCount)
+	for row := 0; row < rowCount; row++ {
+		var placeholders = make([]string, len(upsert.columns))
+		for i := range upsert.columns {
+			placeholders[i] = fmt.Sprintf("$%d", row*len(upsert.columns)+i+1)
+		}
+		rowsPlaceholders[row] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+	var updatedColumns, assignments, currentValues, newValues []string
+	for _, column := range upsert.columns {
+		if column == upsert.keyColumn || contains(upsert.preservedColumns, column) {
+			continue
+		}
+		updatedColumns = append(updatedColumns, column)
+		assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		currentValues = append(currentValues, fmt.Sprintf("%s.%s", upsert.tableName, column))
+		newValues = append(newValues, fmt.Sprintf("EXCLUDED.%s", column))
+	}
+	sqlStmt := fmt.Sprintf(`INSERT INTO %s(%s) VALUES %s ON CONFLICT (%s) `,
+		upsert.tableName, strings.Join(upsert.columns, ", "), strings.Join(rowsPlaceholders, ", "), upsert.keyColumn)
+	if len(updatedColumns) == 0 {
+		sqlStmt += `DO NOTHING`
+	} else {
+		sqlStmt += fmt.Sprintf(`DO UPDATE SET %s WHERE (%s) IS DISTINCT FROM (%s)`,
+			strings.Join(assignments, ", "), strings.Join(currentValues, ", "), strings.Join(newValues, ", "))
+	}
+	return sqlStmt + ` RETURNING (xmax = 0) AS inserted`
+}
+
+// uniqueRows removes the rows with a duplicated key, which cannot be written by the same statement, keeping the last row.
+func uniqueRows(rows [][]interface{}) [][]interface{} {
+	indexes := make(map[string]int)
+	var result [][]interface{}
+	for _, row := range rows {
+		key := fmt.Sprint(row[0])
+		if i, found := indexes[key]; found {
+			result[i] = row
+			continue
+		}
+		indexes[key] = len(result)
+		result = append(result, row)
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+)
+
+func TestUpsertStatement(t *testing.T) {
+	upsert, err := NewUpsert("example", []string{"id", "name", "value", "note"}, []string{"note"})
+	if err != nil {
+		t.Fatalf("Error creating Upsert: %v", err)
+	}
+
+	expectedStatement := `INSERT INTO example(id, name, value, note) VALUES ($1, $2, $3, $4) ON CONFLICT (id) ` +
+		`DO UPDATE SET name = EXCLUDED.name, value = EXCLUDED.value WHERE (example.name, example.value) IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.value) ` +
+		`RETURNING (xmax = 0) AS inserted`
+	assert.Equal(t, expectedStatement, upsert.statement(1), "Expected the upsert statement")
+}
+
+func TestUpsertStatementWithAllColumnsPreserved(t *testing.T) {
+	upsert, err := NewUpsert("example", []string{"id", "note"}, []string{"note"})
+	if err != nil {
+		t.Fatalf("Error creating Upsert: %v", err)
+	}
+
+	expectedStatement := `INSERT INTO example(id, note) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING RETURNING (xmax = 0) AS inserted`
+	assert.Equal(t, expectedStatement, upsert.statement(1), "Expected the upsert statement")
+}
+
+func TestUpsertStatementWithMultipleRows(t *testing.T) {
+	upsert, err := NewUpsert("example", []string{"id", "note"}, []string{"note"})
+	if err != nil {
+		t.Fatalf("Error creating Upsert: %v", err)
+	}
+
+	expectedStatement := `INSERT INTO example(id, note) VALUES ($1, $2), ($3, $4), ($5, $6) ON CONFLICT (id) DO NOTHING RETURNING (xmax = 0) AS inserted`
+	assert.Equal(t, expectedStatement, upsert.statement(3), "Expected the upsert statement")
+}
+
+func TestUpsertExecuteInBatches(t *testing.T) {
+	upsert, err := NewUpsert("example", []string{"id", "name"}, nil)
+	if err != nil {
+		t.Fatalf("Error creating Upsert: %v", err)
+	}
+	upsert.batchSize = 2
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO example").
+		WithArgs(1, "first", 2, "second updated").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true).AddRow(false))
+	mock.ExpectQuery("INSERT INTO example").
+		WithArgs(3, "third").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}))
+
+	var report SyncReport
+	rows := [][]interface{}{{1, "first"}, {2, "second"}, {3, "third"}, {2, "second updated"}}
+	err = upsert.Execute(db, &report, rows)
+	if err != nil {
+		t.Errorf("Error in Upsert Execute: %v", err)
+	}
+	assert.Equal(t, SyncReport{Inserted: 1, Updated: 1, Unchanged: 1}, report, "Expected the sync report")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect two batches: %v", err)
+	}
+}
+
+func TestUpsertThrowsUnknownColumnErrorOnInvalidPreservedColumn(t *testing.T) {
+	_, err := NewUpsert("example", []string{"id", "note"}, []string{"unknown"})
+	if err == nil {
+		t.Fatalf("Expect an error while creating Upsert with an unknown preserved column.")
+	}
+	switch err.(type) {
+	case *UnknownColumnError:
+		return
+	default:
+		t.Errorf("Expect an UnknownColumnError while creating Upsert with an unknown preserved column.")
+	}
+}
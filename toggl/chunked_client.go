@@ -0,0 +1,147 @@
+package toggl
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ChunkedClient implements the Toggl Client interface by splitting a long date range into windows.
+// The windows are retrieved with bounded concurrency from the wrapped Client, and the time entries are deduplicated by ID.
+type ChunkedClient struct {
+	logger      *zap.Logger
+	client      Client
+	window      time.Duration
+	concurrency int
+	// resultCap defines the maximum number of time entries returned by the Toggl API for a single request.
+	resultCap int
+}
+
+// minimumWindow defines the shortest window that is split again when its time entries reach the result cap.
+const minimumWindow = time.Minute
+
+// ResultCapReachedError defines the error of a window whose time entries still reach the result cap of the Toggl API after the window splits.
+type ResultCapReachedError struct {
+	Start time.Time
+	End   time.Time
+	Count int
+}
+
+func (err *ResultCapReachedError) Error() string {
+	return fmt.Sprintf("The %d Toggl time entries between %s and %s reached the API limit, some time entries may be missing.", err.Count, err.Start.Format(time.RFC3339), err.End.Format(time.RFC3339))
+}
+
+// PaginatingClient interface defines the Toggl clients that report whether all the pages of a request are retrieved,
+// so that their time entries are never limited by the result cap.
+type PaginatingClient interface {
+	Paginates() bool
+}
+
+// window struct defines a part of the requested date range.
+type window struct {
+	startTime time.Time
+	endTime   time.Time
+}
+
+// NewChunkedClient creates a new ChunkedClient.
+// The whole range is retrieved with a single request when the window is not greater than zero.
+// The result cap is ignored when the wrapped Client retrieves all the pages, since its windows are never incomplete.
+func NewChunkedClient(logger *zap.Logger, client Client, window time.Duration, concurrency int, resultCap int) *ChunkedClient {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if paginatingClient, ok := client.(PaginatingClient); ok && paginatingClient.Paginates() {
+		resultCap = 0
+	}
+	return &ChunkedClient{
+		logger:      logger,
+		client:      client,
+		window:      window,
+		concurrency: concurrency,
+		resultCap:   resultCap,
+	}
+}
+
+// GetRange retrieves the Toggl Time entries that start between the startTime and endTime instants, one window at a time.
+// The windows whose time entries reach the result cap are split in halves, down to the minimum window, and the
+// ResultCapReachedError is returned when a minimum window still reaches the result cap.
+func (chunkedClient *ChunkedClient) GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
+	windows := chunkedClient.split(startTime, endTime)
+	results := make([][]TogglTimeEntry, len(windows))
+	errs := make([]error, len(windows))
+	semaphore := make(chan struct{}, chunkedClient.concurrency)
+	var waitGroup sync.WaitGroup
+	for i, w := range windows {
+		waitGroup.Add(1)
+		go func(i int, w window) {
+			defer waitGroup.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i], errs[i] = chunkedClient.getWindow(w)
+		}(i, w)
+	}
+	waitGroup.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	var togglTimeEntries []TogglTimeEntry
+	ids := make(map[uint64]bool)
+	for _, result := range results {
+		for _, togglTimeEntry := range result {
+			if ids[togglTimeEntry.Id] {
+				continue
+			}
+			ids[togglTimeEntry.Id] = true
+			togglTimeEntries = append(togglTimeEntries, togglTimeEntry)
+		}
+	}
+	return togglTimeEntries, nil
+}
+
+// ReturnsRunningEntries reports whether the wrapped Client returns the running time entries.
+func (chunkedClient *ChunkedClient) ReturnsRunningEntries() bool {
+	return returnsRunningEntries(chunkedClient.client)
+}
+
+// getWindow retrieves the Toggl Time entries of the window, and splits the window in halves when the time entries reach the result cap.
+func (chunkedClient *ChunkedClient) getWindow(w window) ([]TogglTimeEntry, error) {
+	togglTimeEntries, err := chunkedClient.client.GetRange(w.startTime, w.endTime)
+	if err != nil || chunkedClient.resultCap <= 0 || len(togglTimeEntries) < chunkedClient.resultCap {
+		return togglTimeEntries, err
+	}
+	if w.endTime.Sub(w.startTime) < minimumWindow {
+		return nil, &ResultCapReachedError{Start: w.startTime, End: w.endTime, Count: len(togglTimeEntries)}
+	}
+	middle := w.startTime.Add(w.endTime.Sub(w.startTime) / 2).Truncate(time.Second)
+	chunkedClient.logger.Debug("The Toggl time entries of the window reached the API limit, split the window.",
+		zap.Time("start", w.startTime), zap.Time("end", w.endTime), zap.Int("time entries", len(togglTimeEntries)))
+	firstHalf, err := chunkedClient.getWindow(window{startTime: w.startTime, endTime: middle})
+	if err != nil {
+		return nil, err
+	}
+	secondHalf, err := chunkedClient.getWindow(window{startTime: middle.Add(time.Second), endTime: w.endTime})
+	if err != nil {
+		return nil, err
+	}
+	return append(firstHalf, secondHalf...), nil
+}
+
+// split divides the date range into consecutive windows. Each window ends one second before the next one starts.
+func (chunkedClient *ChunkedClient) split(startTime time.Time, endTime time.Time) []window {
+	if chunkedClient.window <= 0 {
+		return []window{{startTime: startTime, endTime: endTime}}
+	}
+	var windows []window
+	for windowStart := startTime; !windowStart.After(endTime); windowStart = windowStart.Add(chunkedClient.window) {
+		windowEnd := windowStart.Add(chunkedClient.window - time.Second)
+		if windowEnd.After(endTime) {
+			windowEnd = endTime
+		}
+		windows = append(windows, window{startTime: windowStart, endTime: windowEnd})
+	}
+	return windows
+}
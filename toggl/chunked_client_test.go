@@ -0,0 +1,112 @@
+package toggl
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"go.uber.org/zap"
+)
+
+type rangeRecordingClient struct {
+	mutex                 sync.Mutex
+	windows               []window
+	entries               func(startTime time.Time, endTime time.Time) []TogglTimeEntry
+	returnsRunningEntries bool
+}
+
+func (client *rangeRecordingClient) GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
+	client.mutex.Lock()
+	client.windows = append(client.windows, window{startTime: startTime, endTime: endTime})
+	client.mutex.Unlock()
+	return client.entries(startTime, endTime), nil
+}
+
+func (client *rangeRecordingClient) ReturnsRunningEntries() bool {
+	return client.returnsRunningEntries
+}
+
+type paginatingRangeRecordingClient struct {
+	rangeRecordingClient
+}
+
+func (client *paginatingRangeRecordingClient) Paginates() bool {
+	return true
+}
+
+func TestChunkedClientGetRangeSplitsRangeInWindows(t *testing.T) {
+	client := &rangeRecordingClient{entries: func(startTime time.Time, endTime time.Time) []TogglTimeEntry {
+		return []TogglTimeEntry{{Id: uint64(startTime.Day())}, {Id: 100}}
+	}}
+	chunkedClient := NewChunkedClient(zap.NewNop(), client, 7*24*time.Hour, 2, 1000)
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 20, 23, 59, 59, 0, time.UTC)
+
+	togglTimeEntries, err := chunkedClient.GetRange(startTime, endTime)
+	if err != nil {
+		t.Fatalf("Error in ChunkedClient GetRange: %v", err)
+	}
+	sort.Slice(client.windows, func(i, j int) bool { return client.windows[i].startTime.Before(client.windows[j].startTime) })
+	assert.Equal(t, []window{
+		{startTime: startTime, endTime: time.Date(2021, time.Month(02), 07, 23, 59, 59, 0, time.UTC)},
+		{startTime: time.Date(2021, time.Month(02), 8, 0, 0, 0, 0, time.UTC), endTime: time.Date(2021, time.Month(02), 14, 23, 59, 59, 0, time.UTC)},
+		{startTime: time.Date(2021, time.Month(02), 15, 0, 0, 0, 0, time.UTC), endTime: endTime},
+	}, client.windows, "Expected three weekly windows")
+	assert.Equal(t, []TogglTimeEntry{{Id: 1}, {Id: 100}, {Id: 8}, {Id: 15}}, togglTimeEntries, "Expected the time entries deduplicated by ID in window order")
+}
+
+func TestChunkedClientGetRangeSplitsWindowAtTheCap(t *testing.T) {
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 01, 23, 59, 59, 0, time.UTC)
+	client := &rangeRecordingClient{entries: func(windowStart time.Time, windowEnd time.Time) []TogglTimeEntry {
+		if windowStart.Equal(startTime) && windowEnd.Equal(endTime) {
+			return []TogglTimeEntry{{Id: 1}, {Id: 2}}
+		}
+		return []TogglTimeEntry{{Id: uint64(windowStart.Hour())}}
+	}}
+	chunkedClient := NewChunkedClient(zap.NewNop(), client, 0, 1, 2)
+
+	togglTimeEntries, err := chunkedClient.GetRange(startTime, endTime)
+	if err != nil {
+		t.Fatalf("Error in ChunkedClient GetRange: %v", err)
+	}
+	assert.Equal(t, []window{
+		{startTime: startTime, endTime: endTime},
+		{startTime: startTime, endTime: time.Date(2021, time.Month(02), 01, 11, 59, 59, 0, time.UTC)},
+		{startTime: time.Date(2021, time.Month(02), 01, 12, 0, 0, 0, time.UTC), endTime: endTime},
+	}, client.windows, "Expected the window at the cap to be split in halves")
+	assert.Equal(t, []TogglTimeEntry{{Id: 0}, {Id: 12}}, togglTimeEntries, "Expected the time entries of the halves")
+}
+
+func TestChunkedClientGetRangeIgnoresTheCapWithPaginatingClient(t *testing.T) {
+	startTime := time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 01, 9, 0, 30, 0, time.UTC)
+	client := &paginatingRangeRecordingClient{rangeRecordingClient{entries: func(windowStart time.Time, windowEnd time.Time) []TogglTimeEntry {
+		return []TogglTimeEntry{{Id: 1}, {Id: 2}}
+	}}}
+	chunkedClient := NewChunkedClient(zap.NewNop(), client, 0, 1, 2)
+
+	togglTimeEntries, err := chunkedClient.GetRange(startTime, endTime)
+	if err != nil {
+		t.Fatalf("Error in ChunkedClient GetRange: %v", err)
+	}
+	assert.Equal(t, []window{{startTime: startTime, endTime: endTime}}, client.windows, "Expected the window at the cap not to be split")
+	assert.Equal(t, []TogglTimeEntry{{Id: 1}, {Id: 2}}, togglTimeEntries, "Expected all the time entries of the window")
+}
+
+func TestChunkedClientGetRangeThrowsResultCapReachedError(t *testing.T) {
+	client := &rangeRecordingClient{entries: func(startTime time.Time, endTime time.Time) []TogglTimeEntry {
+		return []TogglTimeEntry{{Id: 1}, {Id: 2}}
+	}}
+	chunkedClient := NewChunkedClient(zap.NewNop(), client, 0, 1, 2)
+
+	_, err := chunkedClient.GetRange(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 01, 0, 1, 59, 0, time.UTC))
+	switch err.(type) {
+	case *ResultCapReachedError:
+		return
+	default:
+		t.Errorf("Expect a ResultCapReachedError when the minimum window reaches the cap, got %v", err)
+	}
+}
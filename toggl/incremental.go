@@ -0,0 +1,131 @@
+package toggl
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// SourceTogglWorkspace defines the source of the Toggl workspaces in the "sync_state" database table.
+const SourceTogglWorkspace = "toggl_workspace"
+
+// SinceClient interface defines the Toggl client primitives of the incremental sync.
+type SinceClient interface {
+	GetWorkspaceIds() ([]uint64, error)
+	GetSince(since map[uint64]time.Time) ([]TogglTimeEntry, []uint64, error)
+}
+
+// UnsupportedIncrementalSyncError defines the error of the Toggl clients that can't retrieve the modified time entries.
+type UnsupportedIncrementalSyncError struct {
+}
+
+func (err *UnsupportedIncrementalSyncError) Error() string {
+	return fmt.Sprintf("The incremental sync is supported only with the Toggl API v9.")
+}
+
+// MissingSyncStateError defines the error of the incremental sync of a source never synced before.
+type MissingSyncStateError struct {
+	Source   string
+	SourceId string
+}
+
+func (err *MissingSyncStateError) Error() string {
+	return fmt.Sprintf("The %s %s has never been synced, run a sync with a date range before the incremental sync.", err.Source, err.SourceId)
+}
+
+// StoreIncremental inserts or updates the Toggl time entries modified since the last sync of each workspace, and marks the time entries
+// deleted in Toggl as deleted. The modified time entries of all the workspaces are retrieved at once. The sync time of the workspaces is recorded in the same transaction.
+func (togglTime *TogglTime) StoreIncremental(sinceClient SinceClient, preservedColumns []string) (report storage.SyncReport, deleted int, err error) {
+	upsert, err := storage.NewUpsert("toggl_time", togglTimeColumns, preservedColumns)
+	if err != nil {
+		return
+	}
+	if togglTime.databaseConnection == nil {
+		return report, deleted, &application_errors.DatabaseConnectionError{}
+	}
+	syncedAt := time.Now().UTC().Truncate(time.Second)
+	workspaceIds, err := sinceClient.GetWorkspaceIds()
+	if err != nil {
+		return
+	}
+	syncState, err := storage.GetSyncState(togglTime.databaseConnection, SourceTogglWorkspace)
+	if err != nil {
+		return
+	}
+	sourceIds := make([]string, len(workspaceIds))
+	since := make(map[uint64]time.Time, len(workspaceIds))
+	for i, workspaceId := range workspaceIds {
+		sourceIds[i] = strconv.FormatUint(workspaceId, 10)
+		lastSync, found := syncState[sourceIds[i]]
+		if !found {
+			return report, deleted, &MissingSyncStateError{Source: SourceTogglWorkspace, SourceId: sourceIds[i]}
+		}
+		since[workspaceId] = lastSync.Add(-storage.SyncOverlap)
+	}
+	togglTimeEntries, modifiedDeletedIds, err := sinceClient.GetSince(since)
+	if err != nil {
+		return
+	}
+	deletedIds := make([]string, len(modifiedDeletedIds))
+	for i, deletedId := range modifiedDeletedIds {
+		deletedIds[i] = strconv.FormatUint(deletedId, 10)
+	}
+	for i := range togglTimeEntries {
+		if togglTimeEntries[i].Status == "" {
+			togglTimeEntries[i].Status = StatusCompleted
+		}
+	}
+	var batchReport storage.SyncReport
+	err = storage.WithTransaction(togglTime.databaseConnection, func(tx *sql.Tx) error {
+		err := upsert.Execute(tx, &batchReport, togglTimeRows(togglTimeEntries))
+		if err != nil {
+			return err
+		}
+		if len(deletedIds) > 0 {
+			result, err := tx.Exec(`UPDATE toggl_time SET deleted_at = $2 WHERE id = ANY($1) AND deleted_at IS NULL`, pq.Array(deletedIds), syncedAt)
+			if err != nil {
+				return err
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			deleted = int(rowsAffected)
+		}
+		return storage.SaveSyncState(tx, SourceTogglWorkspace, sourceIds, syncedAt)
+	})
+	if err != nil {
+		return report, 0, err
+	}
+	report = batchReport
+	togglTime.logger.Info("Stored modified time entries", zap.Int("inserted", report.Inserted), zap.Int("updated", report.Updated), zap.Int("unchanged", report.Unchanged), zap.Int("deleted", deleted))
+	return
+}
+
+// InitSyncState records the syncedAt time as the last sync of the workspaces never synced before, so that the incremental sync can start from it.
+// The sync time is recorded only when the synced range ends at the syncedAt time or later, since the incremental sync would otherwise skip the
+// time entries between the endTime and the syncedAt instants.
+func (togglTime *TogglTime) InitSyncState(sinceClient SinceClient, endTime time.Time, syncedAt time.Time) error {
+	if togglTime.databaseConnection == nil {
+		return &application_errors.DatabaseConnectionError{}
+	}
+	if endTime.Before(syncedAt) {
+		togglTime.logger.Info("The sync time of the Toggl workspaces is not recorded, since the synced range ends before now. Sync a range until today before the incremental sync.", zap.Time("end", endTime))
+		return nil
+	}
+	workspaceIds, err := sinceClient.GetWorkspaceIds()
+	if err != nil {
+		return err
+	}
+	sourceIds := make([]string, len(workspaceIds))
+	for i, workspaceId := range workspaceIds {
+		sourceIds[i] = strconv.FormatUint(workspaceId, 10)
+	}
+	return storage.InitSyncState(togglTime.databaseConnection, SourceTogglWorkspace, sourceIds, syncedAt)
+}
@@ -0,0 +1,200 @@
+package toggl
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/lib/pq"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+)
+
+type MockSinceClient struct {
+	since []map[uint64]time.Time
+}
+
+func (mockSinceClient *MockSinceClient) GetWorkspaceIds() ([]uint64, error) {
+	return []uint64{2245503}, nil
+}
+
+func (mockSinceClient *MockSinceClient) GetSince(since map[uint64]time.Time) ([]TogglTimeEntry, []uint64, error) {
+	mockSinceClient.since = append(mockSinceClient.since, since)
+	togglTimeEntries := []TogglTimeEntry{{Id: 86854567, Start: time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), Stop: time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC), Duration: 900, Workspace_id: 2245503}}
+	return togglTimeEntries, []uint64{86854570}, nil
+}
+
+func TestTogglV9ClientGetSince(t *testing.T) {
+	requests := 0
+	togglClient, server := newTestTogglV9Client(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/me/time_entries":
+			requests++
+			assert.Equal(t, "1612170900", r.URL.Query().Get("since"), "Expected the UNIX timestamp of the earliest since instant")
+			fmt.Fprint(w, `[
+				{"id": 1, "description": "modified", "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z", "duration": 900, "workspace_id": 10},
+				{"id": 2, "description": "deleted", "start": "2021-02-01T10:15:00Z", "stop": "2021-02-01T10:30:00Z", "duration": 900, "workspace_id": 10, "server_deleted_at": "2021-02-01T11:00:00Z"},
+				{"id": 3, "description": "other workspace", "start": "2021-02-01T11:15:00Z", "stop": "2021-02-01T11:30:00Z", "duration": 900, "workspace_id": 11},
+				{"id": 4, "description": "not synced workspace", "start": "2021-02-01T12:15:00Z", "stop": "2021-02-01T12:30:00Z", "duration": 900, "workspace_id": 12}
+			]`)
+		case "/api/v9/workspaces/10":
+			fmt.Fprint(w, `{"id": 10, "name": "workspace name"}`)
+		case "/api/v9/workspaces/11":
+			fmt.Fprint(w, `{"id": 11, "name": "other workspace name"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	since := map[uint64]time.Time{10: time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), 11: time.Date(2021, time.Month(02), 01, 10, 0, 0, 0, time.UTC)}
+	togglTimeEntries, deletedIds, err := togglClient.GetSince(since)
+	if err != nil {
+		t.Fatalf("Error in TogglV9Client GetSince: %v", err)
+	}
+	assert.Equal(t, 1, requests, "Expected a single request for all the workspaces")
+	assert.Equal(t, 2, len(togglTimeEntries), "Expected the modified time entries of the workspaces")
+	assert.Equal(t, "modified", togglTimeEntries[0].Description, "Expected the modified time entry of the first workspace")
+	assert.Equal(t, "other workspace", togglTimeEntries[1].Description, "Expected the modified time entry of the second workspace")
+	assert.Equal(t, []uint64{2}, deletedIds, "Expected the deleted time entry of the workspace")
+}
+
+func TestTogglStoreIncremental(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	sinceClient := &MockSinceClient{}
+	lastSync := time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT source_id, synced_at FROM sync_state").
+		WithArgs(SourceTogglWorkspace).
+		WillReturnRows(sqlmock.NewRows([]string{"source_id", "synced_at"}).AddRow("2245503", lastSync))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectExec("UPDATE toggl_time SET deleted_at").
+		WithArgs(pq.Array([]string{"86854570"}), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO sync_state").
+		WithArgs(SourceTogglWorkspace, pq.Array([]string{"2245503"}), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	report, deleted, err := togglTime.StoreIncremental(sinceClient, []string{"trello_card_id"})
+	if err != nil {
+		t.Fatalf("Error in TogglTime StoreIncremental: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected one inserted time entry")
+	assert.Equal(t, 1, deleted, "Expected one time entry marked as deleted")
+	assert.Equal(t, []map[uint64]time.Time{{2245503: lastSync.Add(-storage.SyncOverlap)}}, sinceClient.since, "Expected the time entries modified since the last sync, retrieved once")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled database expectations: %v", err)
+	}
+}
+
+func TestTogglStoreIncrementalThrowsMissingSyncStateError(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT source_id, synced_at FROM sync_state").
+		WithArgs(SourceTogglWorkspace).
+		WillReturnRows(sqlmock.NewRows([]string{"source_id", "synced_at"}))
+
+	_, _, err = togglTime.StoreIncremental(&MockSinceClient{}, nil)
+	assert.Equal(t, &MissingSyncStateError{Source: SourceTogglWorkspace, SourceId: "2245503"}, err, "Expected the missing sync state error")
+}
+
+func TestRunningEntriesClientGetSinceThrowsUnsupportedIncrementalSyncError(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	runningEntriesClient, err := NewRunningEntriesClient(logger, &MockTogglClient{}, "provisional")
+	if err != nil {
+		t.Fatalf("Error creating RunningEntriesClient: %v", err)
+	}
+
+	_, _, err = runningEntriesClient.GetSince(map[uint64]time.Time{2245503: {}})
+	assert.Equal(t, &UnsupportedIncrementalSyncError{}, err, "Expected the unsupported incremental sync error")
+}
+
+func TestTogglInitSyncState(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	syncedAt := time.Date(2021, time.Month(03), 15, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO sync_state").
+		WithArgs(SourceTogglWorkspace, pq.Array([]string{"2245503"}), syncedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = togglTime.InitSyncState(&MockSinceClient{}, time.Date(2021, time.Month(03), 15, 23, 59, 59, 0, time.UTC), syncedAt)
+	if err != nil {
+		t.Fatalf("Error in TogglTime InitSyncState: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the sync time of the workspace to be recorded: %v", err)
+	}
+}
+
+func TestTogglInitSyncStateSkipsRangeEndingBeforeSyncTime(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	err = togglTime.InitSyncState(&MockSinceClient{}, time.Date(2021, time.Month(02), 28, 23, 59, 59, 0, time.UTC), time.Date(2021, time.Month(03), 15, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error in TogglTime InitSyncState: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the sync state to be left empty: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package toggl
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// The actions on the orphan time entries, which are stored in the database but no longer returned by Toggl.
+const (
+	ReconcileReport     = "report"
+	ReconcileDelete     = "delete"
+	ReconcileSoftDelete = "soft-delete"
+)
+
+// TogglOrphanEntry struct defines a stored Toggl time entry that is no longer returned by Toggl, e.g. a deleted or merged time entry.
+type TogglOrphanEntry struct {
+	Id             string
+	Description    string
+	Start          time.Time
+	Duration       int64
+	User_name      string
+	Trello_card_id string
+}
+
+// ReconcileSummary struct defines the result of the reconciliation.
+type ReconcileSummary struct {
+	Retrieved int
+	Stored    int
+	Orphans   int
+	Removed   int
+}
+
+// ReconcileScope struct defines the filters of the stored time entries compared with Toggl, which must match the time entries retrieved
+// from Toggl. An empty list doesn't filter the stored time entries.
+type ReconcileScope struct {
+	WorkspaceIds []uint64
+	UserIds      []uint64
+	ProjectIds   []uint64
+	ClientIds    []uint64
+}
+
+// UnsupportedReconcileModeError defines the unsupported reconcile mode error.
+type UnsupportedReconcileModeError struct {
+	Mode string
+}
+
+func (err *UnsupportedReconcileModeError) Error() string {
+	return fmt.Sprintf("The reconcile mode %s is not supported, choose from 'report', 'delete' and 'soft-delete'.", err.Mode)
+}
+
+// Reconcile compares the time entries that start between the startTime and endTime instants in Toggl with the ones stored in the database.
+// Only the completed time entries stored in the database that match the scope are compared, so a time range without time entries in Toggl
+// reports all the stored time entries of the scope as orphans.
+// The orphan time entries are written in the "toggl_orphan_entries.csv" file, and then either kept, deleted, or marked as deleted in the
+// "deleted_at" column, depending on the mode. The database is not changed when dryRun is true, or when the time entries retrieved from
+// Toggl may be incomplete, e.g. when a window reaches the result cap of the Toggl API and the ResultCapReachedError is returned.
+func (togglTime *TogglTime) Reconcile(startTime time.Time, endTime time.Time, scope ReconcileScope, mode string, dryRun bool) (summary ReconcileSummary, err error) {
+	switch mode {
+	case ReconcileReport, ReconcileDelete, ReconcileSoftDelete:
+	default:
+		return summary, &UnsupportedReconcileModeError{Mode: mode}
+	}
+	if togglTime.databaseConnection == nil {
+		return summary, &application_errors.DatabaseConnectionError{}
+	}
+	togglTimeEntries, err := togglTime.retrieve(startTime, endTime)
+	if err != nil {
+		return
+	}
+	summary.Retrieved = len(togglTimeEntries)
+	retrievedIds := make(map[string]bool, len(togglTimeEntries))
+	for _, togglTimeEntry := range togglTimeEntries {
+		retrievedIds[strconv.FormatUint(togglTimeEntry.Id, 10)] = true
+	}
+	storedEntries, err := togglTime.storedEntries(startTime, endTime, scope)
+	if err != nil {
+		return
+	}
+	summary.Stored = len(storedEntries)
+	var orphanIds []string
+	var orphanEntries []interface{}
+	for _, storedEntry := range storedEntries {
+		if retrievedIds[storedEntry.Id] {
+			continue
+		}
+		orphanIds = append(orphanIds, storedEntry.Id)
+		orphanEntries = append(orphanEntries, storedEntry)
+	}
+	summary.Orphans = len(orphanIds)
+	togglTime.logger.Info("Reconciled time entries", zap.Int("retrieved", summary.Retrieved), zap.Int("stored", summary.Stored), zap.Int("orphans", summary.Orphans))
+	if len(orphanIds) == 0 {
+		return
+	}
+	downloadStructAsCsv, err := storage.NewDownloadStructAsCsv(togglTime.logger)
+	if err != nil {
+		return
+	}
+	err = downloadStructAsCsv.DownloadAll(orphanEntries, "toggl_orphan_entries")
+	if err != nil || mode == ReconcileReport || dryRun {
+		return
+	}
+	summary.Removed, err = togglTime.removeOrphans(orphanIds, mode)
+	return
+}
+
+// storedEntries retrieves the completed time entries of the scope stored in the database that start between the startTime and endTime instants.
+// The time entries already marked as deleted are not retrieved.
+func (togglTime *TogglTime) storedEntries(startTime time.Time, endTime time.Time, scope ReconcileScope) (storedEntries []TogglOrphanEntry, err error) {
+	sqlStmt := `SELECT id, description, start, duration, user_name, trello_card_id FROM toggl_time
+		WHERE start >= $1 AND start <= $2 AND status = $3 AND deleted_at IS NULL`
+	args := []interface{}{startTime, endTime, StatusCompleted}
+	filters := []struct {
+		column string
+		ids    []uint64
+	}{{"workspace_id", scope.WorkspaceIds}, {"user_id", scope.UserIds}, {"project_id", scope.ProjectIds}, {"client_id", scope.ClientIds}}
+	for _, filter := range filters {
+		if len(filter.ids) == 0 {
+			continue
+		}
+		args = append(args, int64Array(filter.ids))
+		sqlStmt += fmt.Sprintf(" AND %s = ANY($%d)", filter.column, len(args))
+	}
+	rows, err := togglTime.databaseConnection.Query(sqlStmt+" ORDER BY start", args...)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := rows.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	for rows.Next() {
+		var storedEntry TogglOrphanEntry
+		err = rows.Scan(&storedEntry.Id, &storedEntry.Description, &storedEntry.Start, &storedEntry.Duration, &storedEntry.User_name, &storedEntry.Trello_card_id)
+		if err != nil {
+			return
+		}
+		storedEntries = append(storedEntries, storedEntry)
+	}
+	return storedEntries, rows.Err()
+}
+
+// removeOrphans either deletes the orphan time entries, or marks them as deleted, in a single transaction.
+func (togglTime *TogglTime) removeOrphans(orphanIds []string, mode string) (removed int, err error) {
+	sqlStmt := `DELETE FROM toggl_time WHERE id = ANY($1)`
+	if mode == ReconcileSoftDelete {
+		sqlStmt = `UPDATE toggl_time SET deleted_at = now() WHERE id = ANY($1) AND deleted_at IS NULL`
+	}
+	err = storage.WithTransaction(togglTime.databaseConnection, func(tx *sql.Tx) error {
+		result, err := tx.Exec(sqlStmt, pq.Array(orphanIds))
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		removed = int(rowsAffected)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	togglTime.logger.Info("Removed orphan time entries", zap.String("mode", mode), zap.Int("count", removed))
+	return removed, nil
+}
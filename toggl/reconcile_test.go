@@ -0,0 +1,177 @@
+package toggl
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/lib/pq"
+)
+
+var storedEntryColumns = []string{"id", "description", "start", "duration", "user_name", "trello_card_id"}
+
+func TestTogglReconcileSoftDeletesOrphanEntries(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id, description, start, duration, user_name, trello_card_id FROM toggl_time").
+		WithArgs(startTime, endTime, StatusCompleted, pq.Int64Array{2245503}, pq.Int64Array{1234}).
+		WillReturnRows(sqlmock.NewRows(storedEntryColumns).
+			AddRow("86854567", "description", time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), 900, "John Doe", "").
+			AddRow("86854570", "deleted in Toggl", time.Date(2021, time.Month(02), 02, 9, 0, 0, 0, time.UTC), 1800, "John Doe", ""))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE toggl_time SET deleted_at").
+		WithArgs(pq.Array([]string{"86854570"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	summary, err := togglTime.Reconcile(startTime, endTime, ReconcileScope{WorkspaceIds: []uint64{2245503}, UserIds: []uint64{1234}}, ReconcileSoftDelete, false)
+	if err != nil {
+		t.Fatalf("Error in TogglTime Reconcile: %v", err)
+	}
+	defer removeOrphanEntriesFile(t)
+	assert.Equal(t, ReconcileSummary{Retrieved: 1, Stored: 2, Orphans: 1, Removed: 1}, summary, "Expected the orphan time entry to be marked as deleted")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled database expectations: %v", err)
+	}
+}
+
+func TestTogglReconcileDryRunKeepsOrphanEntries(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id, description, start, duration, user_name, trello_card_id FROM toggl_time").
+		WillReturnRows(sqlmock.NewRows(storedEntryColumns).
+			AddRow("86854570", "deleted in Toggl", time.Date(2021, time.Month(02), 02, 9, 0, 0, 0, time.UTC), 1800, "John Doe", ""))
+
+	summary, err := togglTime.Reconcile(startTime, endTime, ReconcileScope{}, ReconcileDelete, true)
+	if err != nil {
+		t.Fatalf("Error in TogglTime Reconcile: %v", err)
+	}
+	defer removeOrphanEntriesFile(t)
+	assert.Equal(t, ReconcileSummary{Retrieved: 1, Stored: 1, Orphans: 1}, summary, "Expected the orphan time entry to be only reported")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expected no deletion in dry run: %v", err)
+	}
+}
+
+func TestTogglReconcileDeletesStoredEntriesWithoutTogglEntries(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{emptyTogglEntries: true}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id, description, start, duration, user_name, trello_card_id FROM toggl_time").
+		WithArgs(startTime, endTime, StatusCompleted).
+		WillReturnRows(sqlmock.NewRows(storedEntryColumns).
+			AddRow("86854570", "deleted in Toggl", time.Date(2021, time.Month(02), 02, 9, 0, 0, 0, time.UTC), 1800, "John Doe", ""))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM toggl_time").
+		WithArgs(pq.Array([]string{"86854570"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	summary, err := togglTime.Reconcile(startTime, endTime, ReconcileScope{}, ReconcileDelete, false)
+	if err != nil {
+		t.Fatalf("Error in TogglTime Reconcile: %v", err)
+	}
+	defer removeOrphanEntriesFile(t)
+	assert.Equal(t, ReconcileSummary{Stored: 1, Orphans: 1, Removed: 1}, summary, "Expected the stored time entry of the empty time range to be deleted")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled database expectations: %v", err)
+	}
+}
+
+func TestTogglReconcileThrowsResultCapReachedErrorWithoutRemovingEntries(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, NewChunkedClient(logger, &MockTogglClient{}, 0, 1, 1), db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	startTime := time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 01, 9, 0, 30, 0, time.UTC)
+
+	_, err = togglTime.Reconcile(startTime, endTime, ReconcileScope{}, ReconcileDelete, false)
+	assert.Equal(t, &ResultCapReachedError{Start: startTime, End: endTime, Count: 1}, err, "Expected the result cap reached error")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expected no database change: %v", err)
+	}
+}
+
+func TestTogglReconcileThrowsUnsupportedReconcileModeError(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	_, err = togglTime.Reconcile(time.Time{}, time.Time{}, ReconcileScope{}, "purge", false)
+	assert.Equal(t, &UnsupportedReconcileModeError{Mode: "purge"}, err, "Expected the unsupported reconcile mode error")
+}
+
+func removeOrphanEntriesFile(t *testing.T) {
+	err := os.Remove("toggl_orphan_entries.csv")
+	if err != nil {
+		t.Fatalf("Error on removing temp file: %v", err)
+	}
+}
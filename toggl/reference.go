@@ -0,0 +1,107 @@
+package toggl
+
+import (
+	"database/sql"
+
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// ReferenceClient interface defines the primitives of the Toggl clients that retrieve the reference data.
+type ReferenceClient interface {
+	GetReferenceData() (ReferenceData, error)
+}
+
+// ReferenceData struct defines the Toggl projects, clients and tags, which are referenced by ID from the Toggl time entries.
+type ReferenceData struct {
+	Projects []TogglProjectEntry
+	Clients  []TogglClientEntry
+	Tags     []TogglTagEntry
+}
+
+// TogglProjectEntry struct defines the Toggl project entry.
+type TogglProjectEntry struct {
+	Id           uint64
+	Workspace_id uint64
+	Client_id    uint64
+	Name         string
+	Billable     bool
+	Active       bool
+}
+
+// TogglClientEntry struct defines the Toggl client entry.
+type TogglClientEntry struct {
+	Id           uint64
+	Workspace_id uint64
+	Name         string
+	Archived     bool
+}
+
+// TogglTagEntry struct defines the Toggl tag entry.
+type TogglTagEntry struct {
+	Id           uint64
+	Workspace_id uint64
+	Name         string
+}
+
+// togglProjectColumns defines the columns of the "toggl_project" database table, in the order of the TogglProjectEntry fields.
+var togglProjectColumns = []string{"id", "workspace_id", "client_id", "name", "billable", "active"}
+
+// togglClientColumns defines the columns of the "toggl_client" database table, in the order of the TogglClientEntry fields.
+var togglClientColumns = []string{"id", "workspace_id", "name", "archived"}
+
+// togglTagColumns defines the columns of the "toggl_tag" database table, in the order of the TogglTagEntry fields.
+var togglTagColumns = []string{"id", "workspace_id", "name"}
+
+// StoreReferenceData inserts or updates the Toggl projects, clients and tags into the database, so that the renames reach all the stored time entries.
+// The projects, clients and tags deleted in Toggl are kept, since the stored time entries may reference them.
+func (togglTime *TogglTime) StoreReferenceData(referenceClient ReferenceClient) (report storage.SyncReport, err error) {
+	if togglTime.databaseConnection == nil {
+		return report, &application_errors.DatabaseConnectionError{}
+	}
+	referenceData, err := referenceClient.GetReferenceData()
+	if err != nil {
+		return
+	}
+	projectRows := make([][]interface{}, len(referenceData.Projects))
+	for i, project := range referenceData.Projects {
+		projectRows[i] = []interface{}{project.Id, project.Workspace_id, project.Client_id, project.Name, project.Billable, project.Active}
+	}
+	clientRows := make([][]interface{}, len(referenceData.Clients))
+	for i, client := range referenceData.Clients {
+		clientRows[i] = []interface{}{client.Id, client.Workspace_id, client.Name, client.Archived}
+	}
+	tagRows := make([][]interface{}, len(referenceData.Tags))
+	for i, tag := range referenceData.Tags {
+		tagRows[i] = []interface{}{tag.Id, tag.Workspace_id, tag.Name}
+	}
+	var batchReport storage.SyncReport
+	err = storage.WithTransaction(togglTime.databaseConnection, func(tx *sql.Tx) error {
+		for _, table := range []struct {
+			name    string
+			columns []string
+			rows    [][]interface{}
+		}{
+			{"toggl_project", togglProjectColumns, projectRows},
+			{"toggl_client", togglClientColumns, clientRows},
+			{"toggl_tag", togglTagColumns, tagRows},
+		} {
+			upsert, err := storage.NewUpsert(table.name, table.columns, nil)
+			if err != nil {
+				return err
+			}
+			err = upsert.Execute(tx, &batchReport, table.rows)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	report = batchReport
+	togglTime.logger.Info("Stored reference data", zap.Int("projects", len(projectRows)), zap.Int("clients", len(clientRows)), zap.Int("tags", len(tagRows)))
+	return
+}
@@ -0,0 +1,90 @@
+package toggl
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+)
+
+type MockReferenceClient struct {
+}
+
+func (mockReferenceClient *MockReferenceClient) GetReferenceData() (ReferenceData, error) {
+	return ReferenceData{
+		Projects: []TogglProjectEntry{{Id: 20, Workspace_id: 10, Client_id: 40, Name: "project name", Billable: true, Active: true}},
+		Clients:  []TogglClientEntry{{Id: 40, Workspace_id: 10, Name: "client name"}},
+		Tags:     []TogglTagEntry{{Id: 30, Workspace_id: 10, Name: "tag1"}},
+	}, nil
+}
+
+func TestTogglV9ClientGetReferenceData(t *testing.T) {
+	togglClient, server := newTestTogglV9Client(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/me/workspaces":
+			fmt.Fprint(w, `[{"id": 10, "name": "workspace name"}]`)
+		case "/api/v9/workspaces/10/projects":
+			assert.Equal(t, "1", r.URL.Query().Get("page"), "Expected the first page of projects")
+			fmt.Fprint(w, `[{"id": 20, "workspace_id": 10, "client_id": 40, "name": "project name", "billable": true, "active": true}]`)
+		case "/api/v9/workspaces/10/clients":
+			assert.Equal(t, "both", r.URL.Query().Get("status"), "Expected the active and archived clients")
+			fmt.Fprint(w, `[{"id": 40, "wid": 10, "name": "client name"}, {"id": 41, "wid": 10, "name": "archived client", "archived": true}]`)
+		case "/api/v9/workspaces/10/tags":
+			fmt.Fprint(w, `[{"id": 30, "workspace_id": 10, "name": "tag1"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	referenceData, err := togglClient.GetReferenceData()
+	if err != nil {
+		t.Fatalf("Error in TogglV9Client GetReferenceData: %v", err)
+	}
+	assert.Equal(t, ReferenceData{
+		Projects: []TogglProjectEntry{{Id: 20, Workspace_id: 10, Client_id: 40, Name: "project name", Billable: true, Active: true}},
+		Clients:  []TogglClientEntry{{Id: 40, Workspace_id: 10, Name: "client name"}, {Id: 41, Workspace_id: 10, Name: "archived client", Archived: true}},
+		Tags:     []TogglTagEntry{{Id: 30, Workspace_id: 10, Name: "tag1"}},
+	}, referenceData, "Expected the reference data of the workspace")
+}
+
+func TestTogglStoreReferenceData(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, &MockTogglClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_project").
+		WithArgs(20, 10, 40, "project name", true, true).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+	mock.ExpectQuery("INSERT INTO toggl_client").
+		WithArgs(40, 10, "client name", false).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}))
+	mock.ExpectQuery("INSERT INTO toggl_tag").
+		WithArgs(30, 10, "tag1").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectCommit()
+
+	report, err := togglTime.StoreReferenceData(&MockReferenceClient{})
+	if err != nil {
+		t.Fatalf("Error in TogglTime StoreReferenceData: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1, Updated: 1, Unchanged: 1}, report, "Expected the renamed project and the new tag")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
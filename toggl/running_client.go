@@ -0,0 +1,115 @@
+package toggl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"go.uber.org/zap"
+)
+
+// The states of the Toggl time entries stored in the "status" column.
+const (
+	StatusCompleted = "completed"
+	StatusRunning   = "running"
+)
+
+// RunningEntriesClient implements the Toggl Client interface by handling the running and the zero-length time entries of the wrapped Client.
+// The Toggl API returns a running time entry with a negative duration and without stop time.
+type RunningEntriesClient struct {
+	logger *zap.Logger
+	client Client
+	policy string
+	// now returns the current time, which is the stop time of the provisional time entries.
+	now func() time.Time
+}
+
+// UnsupportedRunningEntriesError defines the unsupported handling of the running time entries error.
+type UnsupportedRunningEntriesError struct {
+	RunningEntries string
+}
+
+func (err *UnsupportedRunningEntriesError) Error() string {
+	return fmt.Sprintf("The Toggl running entries value %s is not supported, choose from 'provisional' and 'skip'.", err.RunningEntries)
+}
+
+// NewRunningEntriesClient creates a new RunningEntriesClient with the policy for the running time entries, either "provisional" or "skip".
+func NewRunningEntriesClient(logger *zap.Logger, client Client, policy string) (*RunningEntriesClient, error) {
+	switch policy {
+	case configuration.RunningEntriesProvisional, configuration.RunningEntriesSkip:
+	default:
+		return nil, &UnsupportedRunningEntriesError{RunningEntries: policy}
+	}
+	return &RunningEntriesClient{
+		logger: logger,
+		client: client,
+		policy: policy,
+		now:    time.Now,
+	}, nil
+}
+
+// GetRange retrieves the Toggl Time entries that start between the startTime and endTime instants.
+// The zero-length time entries are skipped. The running time entries are either skipped, or marked as running with the duration computed up to now.
+func (runningEntriesClient *RunningEntriesClient) GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
+	togglTimeEntries, err := runningEntriesClient.client.GetRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	return runningEntriesClient.handle(togglTimeEntries), nil
+}
+
+// ReturnsRunningEntries reports whether the running time entries of the wrapped Client are returned, i.e. not skipped by the policy.
+func (runningEntriesClient *RunningEntriesClient) ReturnsRunningEntries() bool {
+	return runningEntriesClient.policy == configuration.RunningEntriesProvisional && returnsRunningEntries(runningEntriesClient.client)
+}
+
+// GetWorkspaceIds returns the workspaces of the wrapped Client, which must implement the SinceClient interface.
+func (runningEntriesClient *RunningEntriesClient) GetWorkspaceIds() ([]uint64, error) {
+	sinceClient, ok := runningEntriesClient.client.(SinceClient)
+	if !ok {
+		return nil, &UnsupportedIncrementalSyncError{}
+	}
+	return sinceClient.GetWorkspaceIds()
+}
+
+// GetSince retrieves the Toggl Time entries of the workspaces modified after the since instant of each workspace from the wrapped Client,
+// which must implement the SinceClient interface. The running and the zero-length time entries are handled as in GetRange.
+func (runningEntriesClient *RunningEntriesClient) GetSince(since map[uint64]time.Time) ([]TogglTimeEntry, []uint64, error) {
+	sinceClient, ok := runningEntriesClient.client.(SinceClient)
+	if !ok {
+		return nil, nil, &UnsupportedIncrementalSyncError{}
+	}
+	togglTimeEntries, deletedIds, err := sinceClient.GetSince(since)
+	if err != nil {
+		return nil, nil, err
+	}
+	return runningEntriesClient.handle(togglTimeEntries), deletedIds, nil
+}
+
+// handle skips the zero-length time entries, and either skips the running time entries or marks them as running.
+func (runningEntriesClient *RunningEntriesClient) handle(togglTimeEntries []TogglTimeEntry) []TogglTimeEntry {
+	now := runningEntriesClient.now().UTC().Truncate(time.Second)
+	var handledTimeEntries []TogglTimeEntry
+	zeroLength, running := 0, 0
+	for _, togglTimeEntry := range togglTimeEntries {
+		if togglTimeEntry.Duration < 0 || togglTimeEntry.Stop.IsZero() {
+			running++
+			if runningEntriesClient.policy == configuration.RunningEntriesSkip {
+				continue
+			}
+			togglTimeEntry.Stop = now
+			togglTimeEntry.Duration = int64(now.Sub(togglTimeEntry.Start).Seconds())
+			togglTimeEntry.Status = StatusRunning
+		} else if togglTimeEntry.Duration == 0 {
+			zeroLength++
+			continue
+		} else {
+			togglTimeEntry.Status = StatusCompleted
+		}
+		handledTimeEntries = append(handledTimeEntries, togglTimeEntry)
+	}
+	if zeroLength+running > 0 {
+		runningEntriesClient.logger.Info("Toggl running and zero-length time entries", zap.Int("running", running), zap.Int("zero-length", zeroLength), zap.String("policy", runningEntriesClient.policy))
+	}
+	return handledTimeEntries
+}
@@ -0,0 +1,66 @@
+package toggl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"go.uber.org/zap"
+)
+
+func newRunningEntriesTestClient() *rangeRecordingClient {
+	return &rangeRecordingClient{entries: func(startTime time.Time, endTime time.Time) []TogglTimeEntry {
+		return []TogglTimeEntry{
+			{Id: 1, Start: time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC), Stop: time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC), Duration: 1800},
+			{Id: 2, Start: time.Date(2021, time.Month(02), 01, 10, 0, 0, 0, time.UTC), Stop: time.Date(2021, time.Month(02), 01, 10, 0, 0, 0, time.UTC), Duration: 0},
+			{Id: 3, Start: time.Date(2021, time.Month(02), 01, 11, 0, 0, 0, time.UTC), Duration: -1612177200},
+		}
+	}}
+}
+
+func TestRunningEntriesClientGetRangeStoresProvisionalEntries(t *testing.T) {
+	runningEntriesClient, err := NewRunningEntriesClient(zap.NewNop(), newRunningEntriesTestClient(), configuration.RunningEntriesProvisional)
+	if err != nil {
+		t.Fatalf("Error creating RunningEntriesClient: %v", err)
+	}
+	runningEntriesClient.now = func() time.Time { return time.Date(2021, time.Month(02), 01, 11, 45, 0, 500, time.UTC) }
+
+	togglTimeEntries, err := runningEntriesClient.GetRange(time.Now(), time.Now())
+	if err != nil {
+		t.Fatalf("Error in RunningEntriesClient GetRange: %v", err)
+	}
+	assert.Equal(t, 2, len(togglTimeEntries), "Expected the zero-length time entry to be skipped")
+	assert.Equal(t, StatusCompleted, togglTimeEntries[0].Status, "Expected the completed time entry")
+	assert.Equal(t, TogglTimeEntry{
+		Id:       3,
+		Start:    time.Date(2021, time.Month(02), 01, 11, 0, 0, 0, time.UTC),
+		Stop:     time.Date(2021, time.Month(02), 01, 11, 45, 0, 0, time.UTC),
+		Duration: 2700,
+		Status:   StatusRunning,
+	}, togglTimeEntries[1], "Expected the running time entry with the duration up to now")
+}
+
+func TestRunningEntriesClientGetRangeSkipsRunningEntries(t *testing.T) {
+	runningEntriesClient, err := NewRunningEntriesClient(zap.NewNop(), newRunningEntriesTestClient(), configuration.RunningEntriesSkip)
+	if err != nil {
+		t.Fatalf("Error creating RunningEntriesClient: %v", err)
+	}
+
+	togglTimeEntries, err := runningEntriesClient.GetRange(time.Now(), time.Now())
+	if err != nil {
+		t.Fatalf("Error in RunningEntriesClient GetRange: %v", err)
+	}
+	assert.Equal(t, 1, len(togglTimeEntries), "Expected only the completed time entry")
+	assert.Equal(t, uint64(1), togglTimeEntries[0].Id, "Expected the completed time entry")
+}
+
+func TestNewRunningEntriesClientThrowsUnsupportedRunningEntriesError(t *testing.T) {
+	_, err := NewRunningEntriesClient(zap.NewNop(), newRunningEntriesTestClient(), "ignore")
+	switch err.(type) {
+	case *UnsupportedRunningEntriesError:
+		return
+	default:
+		t.Errorf("Expect an UnsupportedRunningEntriesError in NewRunningEntriesClient with an unknown policy")
+	}
+}
@@ -3,6 +3,8 @@ package toggl
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/lib/pq"
@@ -16,6 +18,12 @@ type Client interface {
 	GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error)
 }
 
+// RunningEntriesReporter interface defines the Toggl clients that report whether the running time entries are returned.
+// A running time entry stored by a previous sync and no longer returned by these clients was discarded in Toggl.
+type RunningEntriesReporter interface {
+	ReturnsRunningEntries() bool
+}
+
 // TogglTime struct defines the Toggl service.
 type TogglTime struct {
 	logger             *zap.Logger
@@ -23,6 +31,16 @@ type TogglTime struct {
 	databaseConnection *sql.DB
 }
 
+// runningEntry struct defines a running time entry stored in the database.
+type runningEntry struct {
+	start       time.Time
+	workspaceId uint64
+	userId      uint64
+}
+
+// togglTimeColumns defines the columns of the "toggl_time" database table, in the order of the TogglTimeEntry fields.
+var togglTimeColumns = []string{"id", "description", "start", "stop", "duration", "billable", "workspace_id", "workspace_name", "project_id", "project_name", "client_id", "client_name", "user_id", "user_name", "tags", "tag_ids", "trello_card_id", "status", "deleted_at"}
+
 // TogglTimeEntry struct defines the Toggl time entry.
 type TogglTimeEntry struct {
 	Id             uint64
@@ -32,10 +50,17 @@ type TogglTimeEntry struct {
 	Duration       int64
 	Billable       bool
 	Workspace_id   uint64
+	Workspace_name string
 	Project_id     uint64
 	Project_name   string
+	Client_id      uint64
+	Client_name    string
+	User_id        uint64
+	User_name      string
 	Tags           []string
+	Tag_ids        []uint64
 	Trello_card_id string
+	Status         string
 }
 
 func (togglTimeEntry TogglTimeEntry) IsPrintable() bool {
@@ -104,23 +129,118 @@ func (togglTime *TogglTime) DownloadAsCsv(startTime time.Time, endTime time.Time
 	return downloadStructAsCsv.DownloadAll(values, "toggl_time_entries")
 }
 
-// Store inserts the Toggl time entries into the database.
-func (togglTime *TogglTime) Store(startTime time.Time, endTime time.Time) (err error) {
+// Store inserts or updates the Toggl time entries into the database.
+// The preservedColumns, e.g. "trello_card_id", keep the value already stored in the database when a time entry is updated.
+// The running time entries stored by a previous sync are reconciled: the range is extended to their start time, so that they are updated
+// with the completed time entries. When the Toggl client returns the running time entries, the ones no longer retrieved, e.g. the discarded
+// timers, are deleted, limited to the workspaces and the users of the retrieved time entries.
+func (togglTime *TogglTime) Store(startTime time.Time, endTime time.Time, preservedColumns []string) (report storage.SyncReport, err error) {
+	upsert, err := storage.NewUpsert("toggl_time", togglTimeColumns, preservedColumns)
+	if err != nil {
+		return
+	}
+	runningEntries, err := togglTime.runningEntries()
+	if err != nil {
+		return
+	}
+	// The zero startTime retrieves all the time entries, including the running ones, so the range is not extended.
+	if !startTime.IsZero() {
+		for _, runningEntry := range runningEntries {
+			if runningEntry.start.Before(startTime) {
+				startTime = runningEntry.start
+			}
+		}
+	}
 	togglTimeEntries, err := togglTime.retrieve(startTime, endTime)
 	if err != nil {
 		return
 	}
 	if len(togglTimeEntries) == 0 {
 		togglTime.logger.Error("Skip the creation of the Toggl time entries into the database.")
-		return &EmptyTimeResultError{}
+		return report, &EmptyTimeResultError{}
 	}
-	for _, togglTimeEntry := range togglTimeEntries {
-		err = togglTime.storeInDatabase(togglTimeEntry)
+	var staleIds []string
+	if returnsRunningEntries(togglTime.togglClient) {
+		staleIds = staleRunningEntryIds(runningEntries, togglTimeEntries)
+	}
+	err = togglTime.storeInDatabase(upsert, &report, togglTimeEntries, staleIds)
+	if err != nil {
+		return
+	}
+	if len(staleIds) > 0 {
+		togglTime.logger.Info("Deleted running time entries no longer in Toggl", zap.Int("count", len(staleIds)))
+	}
+	togglTime.logger.Info("Stored time entries", zap.Int("inserted", report.Inserted), zap.Int("updated", report.Updated), zap.Int("unchanged", report.Unchanged))
+	return
+}
+
+// LastEntryStart retrieves the start time of the most recent Toggl time entry stored in the database.
+// The zero time is returned when the database does not contain any time entry.
+func (togglTime *TogglTime) LastEntryStart() (time.Time, error) {
+	if togglTime.databaseConnection == nil {
+		return time.Time{}, &application_errors.DatabaseConnectionError{}
+	}
+	var lastStart sql.NullTime
+	err := togglTime.databaseConnection.QueryRow(`SELECT max(start) FROM toggl_time`).Scan(&lastStart)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lastStart.Time, nil
+}
+
+// runningEntries retrieves the running time entries stored in the database by ID.
+func (togglTime *TogglTime) runningEntries() (runningEntries map[string]runningEntry, err error) {
+	if togglTime.databaseConnection == nil {
+		return nil, &application_errors.DatabaseConnectionError{}
+	}
+	rows, err := togglTime.databaseConnection.Query(`SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status = $1`, StatusRunning)
+	if err != nil {
+		return
+	}
+	defer func() {
+		sqlerr := rows.Close()
+		if err == nil {
+			err = sqlerr
+		}
+	}()
+	runningEntries = make(map[string]runningEntry)
+	for rows.Next() {
+		var id string
+		var entry runningEntry
+		err = rows.Scan(&id, &entry.start, &entry.workspaceId, &entry.userId)
 		if err != nil {
 			return
 		}
+		runningEntries[id] = entry
 	}
-	return nil
+	return runningEntries, rows.Err()
+}
+
+// staleRunningEntryIds returns the IDs of the stored running time entries that were not retrieved again,
+// limited to the workspaces and the users of the retrieved time entries.
+func staleRunningEntryIds(runningEntries map[string]runningEntry, togglTimeEntries []TogglTimeEntry) []string {
+	retrievedIds := make(map[string]bool, len(togglTimeEntries))
+	workspaceIds := make(map[uint64]bool)
+	userIds := make(map[uint64]bool)
+	for _, togglTimeEntry := range togglTimeEntries {
+		retrievedIds[strconv.FormatUint(togglTimeEntry.Id, 10)] = true
+		workspaceIds[togglTimeEntry.Workspace_id] = true
+		userIds[togglTimeEntry.User_id] = true
+	}
+	var staleIds []string
+	for id, runningEntry := range runningEntries {
+		if !retrievedIds[id] && workspaceIds[runningEntry.workspaceId] && userIds[runningEntry.userId] {
+			staleIds = append(staleIds, id)
+		}
+	}
+	sort.Strings(staleIds)
+	return staleIds
+}
+
+// returnsRunningEntries reports whether the Toggl client returns the running time entries.
+func returnsRunningEntries(client Client) bool {
+	runningEntriesReporter, ok := client.(RunningEntriesReporter)
+	return ok && runningEntriesReporter.ReturnsRunningEntries()
 }
 
 func (togglTime *TogglTime) retrieve(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
@@ -128,40 +248,57 @@ func (togglTime *TogglTime) retrieve(startTime time.Time, endTime time.Time) ([]
 	if err != nil {
 		return nil, err
 	}
+	for i := range togglTimeEntries {
+		if togglTimeEntries[i].Status == "" {
+			togglTimeEntries[i].Status = StatusCompleted
+		}
+	}
 	togglTime.logger.Info("Time entries", zap.Int("count", len(togglTimeEntries)))
 	return togglTimeEntries, nil
 }
 
-func (togglTime *TogglTime) storeInDatabase(togglTimeEntry TogglTimeEntry) (err error) {
+// storeInDatabase writes all the Toggl time entries, and deletes the stale running time entries, in a single transaction, so that a failed sync leaves the database unchanged.
+func (togglTime *TogglTime) storeInDatabase(upsert *storage.Upsert, report *storage.SyncReport, togglTimeEntries []TogglTimeEntry, staleIds []string) error {
 	if togglTime.databaseConnection == nil {
-		err = &application_errors.DatabaseConnectionError{}
-		return
+		return &application_errors.DatabaseConnectionError{}
 	}
-	tx, err := togglTime.databaseConnection.Begin()
-	if err != nil {
-		return
-	}
-	defer func() {
-		switch err {
-		case nil:
-			sqlerr := tx.Commit()
-			if err == nil {
-				err = sqlerr
-			}
-		default:
-			sqlerr := tx.Rollback()
-			if err == nil {
-				err = sqlerr
-			}
+	var batchReport storage.SyncReport
+	err := storage.WithTransaction(togglTime.databaseConnection, func(tx *sql.Tx) error {
+		err := upsert.Execute(tx, &batchReport, togglTimeRows(togglTimeEntries))
+		if err != nil || len(staleIds) == 0 {
+			return err
 		}
-	}()
-	sqlStmt := `INSERT INTO toggl_time(id, description, start, stop, duration, billable, workspace_id, project_id, project_name, tags, trello_card_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, '')`
-	_, err = togglTime.databaseConnection.Exec(
-		sqlStmt,
-		togglTimeEntry.Id, togglTimeEntry.Description, togglTimeEntry.Start, togglTimeEntry.Stop, togglTimeEntry.Duration,
-		togglTimeEntry.Billable, togglTimeEntry.Workspace_id, togglTimeEntry.Project_id, togglTimeEntry.Project_name, pq.Array(togglTimeEntry.Tags))
+		_, err = tx.Exec(`DELETE FROM toggl_time WHERE id = ANY($1) AND status = $2`, pq.Array(staleIds), StatusRunning)
+		return err
+	})
 	if err != nil {
-		return
+		return err
 	}
-	return
+	*report = batchReport
+	return nil
+}
+
+// togglTimeRows converts the Toggl time entries into the rows of the "toggl_time" database table.
+// The IDs are converted into strings, since the SQL driver doesn't support the unsigned integers with the high bit set of the generated IDs.
+// The "deleted_at" column is reset, since a time entry returned by Toggl is not deleted.
+func togglTimeRows(togglTimeEntries []TogglTimeEntry) [][]interface{} {
+	rows := make([][]interface{}, len(togglTimeEntries))
+	for i, togglTimeEntry := range togglTimeEntries {
+		rows[i] = []interface{}{
+			strconv.FormatUint(togglTimeEntry.Id, 10), togglTimeEntry.Description, togglTimeEntry.Start, togglTimeEntry.Stop, togglTimeEntry.Duration,
+			togglTimeEntry.Billable, togglTimeEntry.Workspace_id, togglTimeEntry.Workspace_name, togglTimeEntry.Project_id,
+			togglTimeEntry.Project_name, togglTimeEntry.Client_id, togglTimeEntry.Client_name, togglTimeEntry.User_id, togglTimeEntry.User_name,
+			pq.Array(togglTimeEntry.Tags), int64Array(togglTimeEntry.Tag_ids), togglTimeEntry.Trello_card_id,
+			togglTimeEntry.Status, nil}
+	}
+	return rows
+}
+
+// int64Array converts the IDs into a PostgreSQL bigint array, which doesn't support the unsigned integers.
+func int64Array(ids []uint64) pq.Int64Array {
+	values := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		values[i] = int64(id)
+	}
+	return values
 }
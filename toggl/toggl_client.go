@@ -3,65 +3,149 @@ package toggl
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
 	"net/http"
+	neturl "net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-// TogglClient implements the Toggl Client interface.
+const togglV8BaseUrl = "https://api.track.toggl.com/api/v8/"
+
+// TogglClient implements the Toggl Client interface with the Toggl API v8.
 type TogglClient struct {
 	logger        *zap.Logger
 	configuration configuration.TogglConfiguration
+	httpClient    *httpclient.Client
+	baseUrl       string
 	projectsData  map[uint64]ProjectData
+	projectsMutex sync.Mutex
+	// clientsData caches the clients by client ID.
+	clientsData  map[uint64]ClientData
+	clientsMutex sync.Mutex
+	// workspacesData caches the workspaces by workspace ID.
+	workspacesData  map[uint64]WorkspaceData
+	workspacesMutex sync.Mutex
 }
 
 // TimeEntry struct defines the Toggl Time entry.
 type TimeEntry struct {
-	Id          uint64    `json:'id'`
-	Description string    `json:'description'`
-	Start       time.Time `json:'start'`
-	Stop        time.Time `json:'stop'`
-	Duration    int64     `json:'duration'`
-	Billable    bool      `json:'billable'`
-	Wid         uint64    `json:'wid'`
-	Pid         uint64    `json:'pid'`
-	Tags        []string  `json:'tags'`
+	Id          uint64    `json:"id"`
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+	Stop        time.Time `json:"stop"`
+	Duration    int64     `json:"duration"`
+	Billable    bool      `json:"billable"`
+	Wid         uint64    `json:"wid"`
+	Pid         uint64    `json:"pid"`
+	Tags        []string  `json:"tags"`
 }
 
 // Project struct defines the Project entry.
 type Project struct {
-	Data ProjectData `json:'data'`
+	Data ProjectData `json:"data"`
 }
 
 // ProjectData struct defines the Project Data entry.
 type ProjectData struct {
-	Id        uint64    `json:'id'`
-	Wid       uint64    `json:'wid'`
-	Cid       uint64    `json:'cid'`
-	Name      string    `json:'name'`
-	Billable  bool      `json:'billable'`
-	IsPrivate bool      `json:'is_private'`
-	Active    bool      `json:'active'`
-	At        time.Time `json:'at'`
-	Template  bool      `json:'template'`
-	Color     string    `json:'color'`
+	Id        uint64    `json:"id"`
+	Wid       uint64    `json:"wid"`
+	Cid       uint64    `json:"cid"`
+	Name      string    `json:"name"`
+	Billable  bool      `json:"billable"`
+	IsPrivate bool      `json:"is_private"`
+	Active    bool      `json:"active"`
+	At        time.Time `json:"at"`
+	Template  bool      `json:"template"`
+	Color     string    `json:"color"`
+}
+
+// ClientEntry struct defines the Client entry.
+type ClientEntry struct {
+	Data ClientData `json:"data"`
+}
+
+// ClientData struct defines the Client Data entry.
+type ClientData struct {
+	Id    uint64    `json:"id"`
+	Wid   uint64    `json:"wid"`
+	Name  string    `json:"name"`
+	Notes string    `json:"notes"`
+	At    time.Time `json:"at"`
+}
+
+// Workspace struct defines the Workspace entry.
+type Workspace struct {
+	Data WorkspaceData `json:"data"`
+}
+
+// WorkspaceData struct defines the Workspace Data entry.
+type WorkspaceData struct {
+	Id      uint64    `json:"id"`
+	Name    string    `json:"name"`
+	Premium bool      `json:"premium"`
+	At      time.Time `json:"at"`
+}
+
+// UnsupportedApiVersionError defines the unsupported Toggl API version error.
+type UnsupportedApiVersionError struct {
+	ApiVersion string
+}
+
+func (err *UnsupportedApiVersionError) Error() string {
+	return fmt.Sprintf("The Toggl API version %s is not supported, choose from 'v8', 'v9' and 'reports'.", err.ApiVersion)
+}
+
+// NewClient creates the Toggl Client for the Toggl API version in the configuration.
+// The date ranges are split into windows, and the running time entries are handled, according to the configuration.
+func NewClient(config configuration.Configuration, logger *zap.Logger, httpClient *httpclient.Client) (Client, error) {
+	var client Client
+	switch config.TogglConfiguration.ApiVersion {
+	case "v8":
+		client = NewTogglClientWithHttpClient(config, logger, httpClient)
+	case "v9":
+		client = NewTogglV9ClientWithHttpClient(config, logger, httpClient)
+	case "reports":
+		client = NewTogglReportsClientWithHttpClient(config, logger, httpClient)
+	default:
+		return nil, &UnsupportedApiVersionError{ApiVersion: config.TogglConfiguration.ApiVersion}
+	}
+	window := time.Duration(config.TogglConfiguration.WindowInDays) * 24 * time.Hour
+	chunkedClient := NewChunkedClient(logger, client, window, config.TogglConfiguration.Concurrency, config.TogglConfiguration.MaxEntriesPerRequest)
+	runningEntriesClient, err := NewRunningEntriesClient(logger, chunkedClient, config.TogglConfiguration.RunningEntries)
+	if err != nil {
+		return nil, err
+	}
+	return runningEntriesClient, nil
 }
 
 // NewTogglClient creates a new TogglClient.
 func NewTogglClient(config configuration.Configuration, logger *zap.Logger) *TogglClient {
+	return NewTogglClientWithHttpClient(config, logger, newDefaultHttpClient(config, logger))
+}
+
+// NewTogglClientWithHttpClient creates a new TogglClient that executes the requests with the HTTP client.
+func NewTogglClientWithHttpClient(config configuration.Configuration, logger *zap.Logger, httpClient *httpclient.Client) *TogglClient {
 	return &TogglClient{
-		logger:        logger,
-		configuration: config.TogglConfiguration,
-		projectsData:  make(map[uint64]ProjectData),
+		logger:         logger,
+		configuration:  config.TogglConfiguration,
+		httpClient:     httpClient,
+		baseUrl:        baseUrl(config.TogglConfiguration.BaseUrl, togglV8BaseUrl),
+		projectsData:   make(map[uint64]ProjectData),
+		clientsData:    make(map[uint64]ClientData),
+		workspacesData: make(map[uint64]WorkspaceData),
 	}
 }
 
 // GetProjectData retrieves the Project Data from a Project ID.
 func (togglClient *TogglClient) GetProjectData(projectId uint64) (ProjectData, error) {
-	url := "https://api.track.toggl.com/api/v8/projects/" + strconv.FormatUint(projectId, 10)
+	url := togglClient.baseUrl + "projects/" + strconv.FormatUint(projectId, 10)
 	resp, err := togglClient.executeHttpGet(url)
 	if err != nil {
 		return ProjectData{}, err
@@ -76,9 +160,43 @@ func (togglClient *TogglClient) GetProjectData(projectId uint64) (ProjectData, e
 	return project.Data, nil
 }
 
-// GetRange retrieves the Toggl Time entries between the startTime and endTime time range.
+// GetClientData retrieves the Client Data from a Client ID.
+func (togglClient *TogglClient) GetClientData(clientId uint64) (ClientData, error) {
+	url := togglClient.baseUrl + "clients/" + strconv.FormatUint(clientId, 10)
+	resp, err := togglClient.executeHttpGet(url)
+	if err != nil {
+		return ClientData{}, err
+	}
+	defer resp.Body.Close()
+
+	var client ClientEntry
+	unmrshalErr := json.NewDecoder(resp.Body).Decode(&client)
+	if unmrshalErr != nil {
+		return ClientData{}, unmrshalErr
+	}
+	return client.Data, nil
+}
+
+// GetWorkspaceData retrieves the Workspace Data from a Workspace ID.
+func (togglClient *TogglClient) GetWorkspaceData(workspaceId uint64) (WorkspaceData, error) {
+	url := togglClient.baseUrl + "workspaces/" + strconv.FormatUint(workspaceId, 10)
+	resp, err := togglClient.executeHttpGet(url)
+	if err != nil {
+		return WorkspaceData{}, err
+	}
+	defer resp.Body.Close()
+
+	var workspace Workspace
+	unmrshalErr := json.NewDecoder(resp.Body).Decode(&workspace)
+	if unmrshalErr != nil {
+		return WorkspaceData{}, unmrshalErr
+	}
+	return workspace.Data, nil
+}
+
+// GetRange retrieves the Toggl Time entries that start between the startTime and endTime instants.
 func (togglClient *TogglClient) GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
-	url := "https://api.track.toggl.com/api/v8/time_entries?start_date=" + startTime.Format("2006-01-02") + "T00%3A00%3A00%2B00%3A00&end_date=" + endTime.Format("2006-01-02") + "T23%3A59%3A59%2B00%3A00"
+	url := togglClient.baseUrl + "time_entries?start_date=" + neturl.QueryEscape(startTime.Format(time.RFC3339)) + "&end_date=" + neturl.QueryEscape(endTime.Format(time.RFC3339))
 	resp, err := togglClient.executeHttpGet(url)
 	if err != nil {
 		return nil, err
@@ -93,7 +211,15 @@ func (togglClient *TogglClient) GetRange(startTime time.Time, endTime time.Time)
 
 	var togglTimeEntries = make([]TogglTimeEntry, len(timeEntries))
 	for i, timeEntry := range timeEntries {
-		projectName, err := togglClient.getProjectName(timeEntry)
+		projectData, err := togglClient.getProjectData(timeEntry)
+		if err != nil {
+			return nil, err
+		}
+		clientName, err := togglClient.getClientName(projectData.Cid)
+		if err != nil {
+			return nil, err
+		}
+		workspaceName, err := togglClient.getWorkspaceName(timeEntry.Wid)
 		if err != nil {
 			return nil, err
 		}
@@ -105,8 +231,11 @@ func (togglClient *TogglClient) GetRange(startTime time.Time, endTime time.Time)
 			Duration:       timeEntry.Duration,
 			Billable:       timeEntry.Billable,
 			Workspace_id:   timeEntry.Wid,
+			Workspace_name: workspaceName,
 			Project_id:     timeEntry.Pid,
-			Project_name:   projectName,
+			Project_name:   projectData.Name,
+			Client_id:      projectData.Cid,
+			Client_name:    clientName,
 			Tags:           timeEntry.Tags,
 			Trello_card_id: "",
 		}
@@ -115,33 +244,90 @@ func (togglClient *TogglClient) GetRange(startTime time.Time, endTime time.Time)
 	return togglTimeEntries, nil
 }
 
+// ReturnsRunningEntries reports that the Toggl API v8 returns the running time entries.
+func (togglClient *TogglClient) ReturnsRunningEntries() bool {
+	return true
+}
+
 // getAuthorizationHeader configures the Authorization Http Header from the Toggl API Token.
 func (togglClient *TogglClient) getAuthorizationHeader() string {
-	apiToken := togglClient.configuration.ApiToken + ":api_token"
-	return "Basic " + base64.StdEncoding.EncodeToString([]byte(apiToken))
+	return basicAuthorization(togglClient.configuration.ApiToken)
+}
+
+// newDefaultHttpClient creates the HTTP client with the default timeout, and the request rate and retries in the configuration.
+func newDefaultHttpClient(config configuration.Configuration, logger *zap.Logger) *httpclient.Client {
+	return httpclient.NewClient(logger, &http.Client{Timeout: httpclient.DefaultTimeout}, config.TogglConfiguration.RequestsPerSecond, config.TogglConfiguration.MaxRetries)
+}
+
+// baseUrl returns the configured base URL with a trailing slash, or the default base URL when not configured.
+func baseUrl(configuredBaseUrl string, defaultBaseUrl string) string {
+	if configuredBaseUrl == "" {
+		return defaultBaseUrl
+	}
+	return strings.TrimSuffix(configuredBaseUrl, "/") + "/"
+}
+
+// basicAuthorization creates the Basic Authorization Http Header value from the Toggl API Token.
+func basicAuthorization(apiToken string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(apiToken+":api_token"))
 }
 
 // executeHttpGet executes the Http call from a URL and returns the response object.
 func (togglClient *TogglClient) executeHttpGet(url string) (*http.Response, error) {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Authorization", togglClient.getAuthorizationHeader())
-	return client.Do(req)
+	return togglClient.httpClient.Get(url, http.Header{"Authorization": {togglClient.getAuthorizationHeader()}})
 }
 
-// getProjectName retrieves the Project Name from the Project ID in the TimeEntry object.
-func (togglClient *TogglClient) getProjectName(timeEntry TimeEntry) (string, error) {
+// getProjectData retrieves the Project Data from the Project ID in the TimeEntry object.
+func (togglClient *TogglClient) getProjectData(timeEntry TimeEntry) (ProjectData, error) {
+	if timeEntry.Pid == 0 {
+		return ProjectData{}, nil
+	}
+	togglClient.projectsMutex.Lock()
+	defer togglClient.projectsMutex.Unlock()
 	if projectData, found := togglClient.projectsData[timeEntry.Pid]; found {
-		return projectData.Name, nil
+		return projectData, nil
 	} else {
 		projectData, err := togglClient.GetProjectData(timeEntry.Pid)
 		if err != nil {
-			return "", err
+			return ProjectData{}, err
 		}
 		togglClient.projectsData[timeEntry.Pid] = projectData
-		return projectData.Name, nil
+		return projectData, nil
+	}
+}
+
+// getClientName retrieves the Client Name from the Client ID of the project.
+func (togglClient *TogglClient) getClientName(clientId uint64) (string, error) {
+	if clientId == 0 {
+		return "", nil
+	}
+	togglClient.clientsMutex.Lock()
+	defer togglClient.clientsMutex.Unlock()
+	if clientData, found := togglClient.clientsData[clientId]; found {
+		return clientData.Name, nil
+	}
+	clientData, err := togglClient.GetClientData(clientId)
+	if err != nil {
+		return "", err
+	}
+	togglClient.clientsData[clientId] = clientData
+	return clientData.Name, nil
+}
+
+// getWorkspaceName retrieves the Workspace Name from the Workspace ID in the TimeEntry object.
+func (togglClient *TogglClient) getWorkspaceName(workspaceId uint64) (string, error) {
+	if workspaceId == 0 {
+		return "", nil
+	}
+	togglClient.workspacesMutex.Lock()
+	defer togglClient.workspacesMutex.Unlock()
+	if workspaceData, found := togglClient.workspacesData[workspaceId]; found {
+		return workspaceData.Name, nil
+	}
+	workspaceData, err := togglClient.GetWorkspaceData(workspaceId)
+	if err != nil {
+		return "", err
 	}
+	togglClient.workspacesData[workspaceId] = workspaceData
+	return workspaceData.Name, nil
 }
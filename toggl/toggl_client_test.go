@@ -0,0 +1,43 @@
+package toggl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
+)
+
+func TestTogglClientGetRangeWithoutProject(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v8/time_entries":
+			fmt.Fprint(w, `[{"id": 1, "description": "without project", "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z", "duration": 900, "wid": 10}]`)
+		case "/api/v8/workspaces/10":
+			fmt.Fprint(w, `{"data": {"id": 10, "name": "workspace name"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	config := configuration.Configuration{TogglConfiguration: configuration.TogglConfiguration{ApiToken: "token", BaseUrl: server.URL + "/api/v8"}}
+	togglClient := NewTogglClientWithHttpClient(config, logger, httpclient.NewClient(logger, server.Client(), 0, 0))
+
+	togglTimeEntries, err := togglClient.GetRange(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error in TogglClient GetRange: %v", err)
+	}
+	assert.Equal(t, 1, len(togglTimeEntries), "Expected the time entry without project")
+	assert.Equal(t, uint64(0), togglTimeEntries[0].Project_id, "Expected no project")
+	assert.Equal(t, "", togglTimeEntries[0].Project_name, "Expected no project name")
+	assert.Equal(t, "workspace name", togglTimeEntries[0].Workspace_name, "Expected the workspace name")
+}
@@ -0,0 +1,359 @@
+package toggl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"go.uber.org/zap"
+)
+
+// exportDateLayouts defines the Go layouts of the date formats of the Toggl detailed report exports.
+var exportDateLayouts = []struct {
+	format string
+	layout string
+}{
+	{format: "YYYY-MM-DD", layout: "2006-01-02"},
+	{format: "MM/DD/YYYY", layout: "01/02/2006"},
+	{format: "DD/MM/YYYY", layout: "02/01/2006"},
+	{format: "DD.MM.YYYY", layout: "02.01.2006"},
+	{format: "DD-MM-YYYY", layout: "02-01-2006"},
+	{format: "YYYY/MM/DD", layout: "2006/01/02"},
+}
+
+// exportTimeLayouts defines the Go layouts of the time formats of the Toggl detailed report exports, in 24-hour and 12-hour clock.
+var exportTimeLayouts = []string{"15:04:05", "15:04", "3:04:05 PM", "3:04 PM"}
+
+// generatedIdBit marks the IDs generated for the exported time entries, so that they do not collide with the Toggl time entry IDs.
+const generatedIdBit = uint64(1) << 63
+
+// TogglExportClient implements the Toggl Client interface, reading the time entries from a Toggl detailed report export.
+// The export is either the CSV file or the JSON file of the detailed report.
+type TogglExportClient struct {
+	logger        *zap.Logger
+	configuration configuration.TogglConfiguration
+	fileName      string
+}
+
+// DetailedReportEntry struct defines the time entry of the Toggl detailed report JSON export.
+type DetailedReportEntry struct {
+	Id          uint64    `json:"id"`
+	Pid         uint64    `json:"pid"`
+	Uid         uint64    `json:"uid"`
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Dur         int64     `json:"dur"`
+	User        string    `json:"user"`
+	Client      string    `json:"client"`
+	Project     string    `json:"project"`
+	Tags        []string  `json:"tags"`
+	IsBillable  bool      `json:"is_billable"`
+}
+
+// DetailedReport struct defines the Toggl detailed report JSON export.
+type DetailedReport struct {
+	Data []DetailedReportEntry `json:"data"`
+}
+
+// InvalidTogglExportError defines the invalid Toggl detailed report export error.
+type InvalidTogglExportError struct {
+	FileName string
+	Line     int
+	Message  string
+}
+
+func (err *InvalidTogglExportError) Error() string {
+	if err.Line > 0 {
+		return fmt.Sprintf("The Toggl export %s is not valid at line %d: %s.", err.FileName, err.Line, err.Message)
+	}
+	return fmt.Sprintf("The Toggl export %s is not valid: %s.", err.FileName, err.Message)
+}
+
+// NewTogglExportClient creates a new TogglExportClient.
+func NewTogglExportClient(config configuration.Configuration, logger *zap.Logger, fileName string) *TogglExportClient {
+	return &TogglExportClient{
+		logger:        logger,
+		configuration: config.TogglConfiguration,
+		fileName:      fileName,
+	}
+}
+
+// GetRange retrieves the time entries of the export that start between the startTime and endTime.
+// All the time entries of the export are retrieved when both startTime and endTime are zero.
+func (togglExportClient *TogglExportClient) GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
+	data, err := os.ReadFile(togglExportClient.fileName)
+	if err != nil {
+		return nil, err
+	}
+	var togglTimeEntries []TogglTimeEntry
+	if strings.EqualFold(filepath.Ext(togglExportClient.fileName), ".json") {
+		togglTimeEntries, err = togglExportClient.parseJson(data)
+	} else {
+		togglTimeEntries, err = togglExportClient.parseCsv(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	assignGeneratedIds(togglTimeEntries)
+	togglExportClient.logger.Info("Toggl export time entries", zap.Int("count", len(togglTimeEntries)))
+	if startTime.IsZero() && endTime.IsZero() {
+		return togglTimeEntries, nil
+	}
+	var rangeTimeEntries []TogglTimeEntry
+	for _, togglTimeEntry := range togglTimeEntries {
+		if !togglTimeEntry.Start.Before(startTime) && !togglTimeEntry.Start.After(endTime) {
+			rangeTimeEntries = append(rangeTimeEntries, togglTimeEntry)
+		}
+	}
+	return rangeTimeEntries, nil
+}
+
+// ReturnsRunningEntries reports that the Toggl detailed report exports contain only the completed time entries.
+func (togglExportClient *TogglExportClient) ReturnsRunningEntries() bool {
+	return false
+}
+
+// parseJson parses the detailed report JSON export, either the report object or the array of time entries.
+func (togglExportClient *TogglExportClient) parseJson(data []byte) ([]TogglTimeEntry, error) {
+	var detailedReport DetailedReport
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &detailedReport.Data)
+	} else {
+		err = json.Unmarshal(data, &detailedReport)
+	}
+	if err != nil {
+		return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Message: err.Error()}
+	}
+	togglTimeEntries := make([]TogglTimeEntry, len(detailedReport.Data))
+	for i, entry := range detailedReport.Data {
+		togglTimeEntries[i] = TogglTimeEntry{
+			Id:           entry.Id,
+			Description:  entry.Description,
+			Start:        entry.Start.UTC(),
+			Stop:         entry.End.UTC(),
+			Duration:     entry.Dur / 1000,
+			Billable:     entry.IsBillable,
+			Project_id:   entry.Pid,
+			Project_name: entry.Project,
+			Client_name:  entry.Client,
+			User_id:      entry.Uid,
+			User_name:    entry.User,
+			Tags:         entry.Tags,
+		}
+	}
+	return togglTimeEntries, nil
+}
+
+// parseCsv parses the detailed report CSV export.
+// The columns are identified by the header names, and the delimiter is either the comma or the semicolon used by some locales.
+func (togglExportClient *TogglExportClient) parseCsv(data []byte) ([]TogglTimeEntry, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine := data
+	if index := bytes.IndexByte(data, '\n'); index >= 0 {
+		firstLine = data[:index]
+	}
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Message: err.Error()}
+	}
+	if len(records) == 0 {
+		return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Message: "the file is empty"}
+	}
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"description", "start date", "start time", "duration"} {
+		if _, found := columns[name]; !found {
+			return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Line: 1, Message: fmt.Sprintf("the column %q is missing", name)}
+		}
+	}
+	value := func(record []string, names ...string) string {
+		for _, name := range names {
+			if i, found := columns[name]; found && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+		}
+		return ""
+	}
+	location, err := time.LoadLocation(togglExportClient.configuration.ExportTimezone)
+	if err != nil {
+		return nil, err
+	}
+	records = records[1:]
+	startDates := make([]string, len(records))
+	for i, record := range records {
+		startDates[i] = value(record, "start date")
+	}
+	dateLayout, err := togglExportClient.dateLayout(startDates)
+	if err != nil {
+		return nil, err
+	}
+	togglTimeEntries := make([]TogglTimeEntry, len(records))
+	for i, record := range records {
+		line := i + 2
+		start, err := parseExportDateTime(dateLayout, value(record, "start date"), value(record, "start time"), location)
+		if err != nil {
+			return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Line: line, Message: err.Error()}
+		}
+		duration, err := parseExportDuration(value(record, "duration"))
+		if err != nil {
+			return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Line: line, Message: err.Error()}
+		}
+		stop := start.Add(time.Duration(duration) * time.Second)
+		if value(record, "end date") != "" && value(record, "end time") != "" {
+			stop, err = parseExportDateTime(dateLayout, value(record, "end date"), value(record, "end time"), location)
+			if err != nil {
+				return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Line: line, Message: err.Error()}
+			}
+		}
+		var id uint64
+		if value(record, "id") != "" {
+			id, err = strconv.ParseUint(value(record, "id"), 10, 64)
+			if err != nil {
+				return nil, &InvalidTogglExportError{FileName: togglExportClient.fileName, Line: line, Message: err.Error()}
+			}
+		}
+		togglTimeEntries[i] = TogglTimeEntry{
+			Id:           id,
+			Description:  value(record, "description"),
+			Start:        start.UTC(),
+			Stop:         stop.UTC(),
+			Duration:     duration,
+			Billable:     parseExportBool(value(record, "billable")),
+			Project_name: value(record, "project"),
+			Client_name:  value(record, "client"),
+			User_name:    value(record, "user", "member"),
+			Tags:         splitExportTags(value(record, "tags")),
+		}
+	}
+	return togglTimeEntries, nil
+}
+
+// dateLayout returns the Go layout of the configured date format, or of the only date format that parses all the dates.
+// The detection fails when multiple date formats parse all the dates, e.g. the dates valid in both the MM/DD/YYYY and the DD/MM/YYYY formats.
+func (togglExportClient *TogglExportClient) dateLayout(dates []string) (string, error) {
+	dateFormat := togglExportClient.configuration.ExportDateFormat
+	if dateFormat != "" {
+		for _, dateLayout := range exportDateLayouts {
+			if strings.EqualFold(dateFormat, dateLayout.format) {
+				return dateLayout.layout, nil
+			}
+		}
+		return "", &InvalidTogglExportError{FileName: togglExportClient.fileName, Message: fmt.Sprintf("the date format %q is not supported", dateFormat)}
+	}
+	var matchingFormats []string
+	var layout string
+	for _, dateLayout := range exportDateLayouts {
+		if parsesAll(dateLayout.layout, dates) {
+			matchingFormats = append(matchingFormats, dateLayout.format)
+			layout = dateLayout.layout
+		}
+	}
+	switch len(matchingFormats) {
+	case 0:
+		return "", &InvalidTogglExportError{FileName: togglExportClient.fileName, Message: "the date format is not recognized, define the property TOGGL_EXPORT_DATE_FORMAT"}
+	case 1:
+		return layout, nil
+	default:
+		return "", &InvalidTogglExportError{FileName: togglExportClient.fileName, Message: fmt.Sprintf("the date format is ambiguous between %s, define the property TOGGL_EXPORT_DATE_FORMAT", strings.Join(matchingFormats, " and "))}
+	}
+}
+
+func parsesAll(layout string, dates []string) bool {
+	for _, date := range dates {
+		if _, err := time.Parse(layout, date); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func parseExportDateTime(dateLayout string, date string, clock string, location *time.Location) (time.Time, error) {
+	for _, timeLayout := range exportTimeLayouts {
+		dateTime, err := time.ParseInLocation(dateLayout+" "+timeLayout, date+" "+strings.ToUpper(clock), location)
+		if err == nil {
+			return dateTime, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date and time %q %q", date, clock)
+}
+
+// parseExportDuration parses the duration in seconds, either in the "hh:mm:ss" format or as decimal hours.
+func parseExportDuration(value string) (int64, error) {
+	if !strings.Contains(value, ":") {
+		hours, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return int64(hours*time.Hour.Seconds() + 0.5), nil
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	var seconds int64
+	for _, part := range parts {
+		number, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		seconds = seconds*60 + number
+	}
+	if len(parts) == 2 {
+		seconds *= 60
+	}
+	return seconds, nil
+}
+
+func parseExportBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "yes", "true", "1":
+		return true
+	}
+	return false
+}
+
+func splitExportTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// assignGeneratedIds assigns a stable ID to the time entries without an ID, so that importing the same export again updates the same entries.
+// The ID is the FNV-1a hash of the user, the start, the stop, the description and the project of the time entry,
+// together with the occurrence number of identical time entries.
+func assignGeneratedIds(togglTimeEntries []TogglTimeEntry) {
+	occurrences := make(map[string]int)
+	for i, togglTimeEntry := range togglTimeEntries {
+		if togglTimeEntry.Id != 0 {
+			continue
+		}
+		key := strings.Join([]string{togglTimeEntry.User_name, togglTimeEntry.Start.Format(time.RFC3339), togglTimeEntry.Stop.Format(time.RFC3339),
+			togglTimeEntry.Description, togglTimeEntry.Client_name, togglTimeEntry.Project_name}, "\x1f")
+		occurrence := occurrences[key]
+		occurrences[key]++
+		hash := fnv.New64a()
+		hash.Write([]byte(key + "\x1f" + strconv.Itoa(occurrence)))
+		togglTimeEntries[i].Id = hash.Sum64() | generatedIdBit
+	}
+}
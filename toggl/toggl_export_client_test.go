@@ -0,0 +1,163 @@
+package toggl
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+)
+
+func newTogglExportClient(t *testing.T, fileName string, data string, togglConfiguration configuration.TogglConfiguration) *TogglExportClient {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	filePath := filepath.Join(t.TempDir(), fileName)
+	err = os.WriteFile(filePath, []byte(data), 0644)
+	if err != nil {
+		t.Fatalf("Error writing the Toggl export: %v", err)
+	}
+	return NewTogglExportClient(configuration.Configuration{TogglConfiguration: togglConfiguration}, logger, filePath)
+}
+
+func TestTogglExportClientGetRangeFromCsv(t *testing.T) {
+	data := "\ufeffUser,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount ()\n" +
+		"John Doe,john@example.com,Acme,Website,,Implement the login page,Yes,02/01/2021,9:15:00 AM,02/01/2021,10:45:00 AM,01:30:00,\"card:aBcD1234, frontend\",\n" +
+		"Jane Roe,jane@example.com,,Internal,,Weekly meeting,No,02/13/2021,2:00:00 PM,02/13/2021,2:30:00 PM,00:30:00,,\n"
+	togglExportClient := newTogglExportClient(t, "detailed.csv", data, configuration.TogglConfiguration{ExportTimezone: "Europe/Rome"})
+
+	togglTimeEntries, err := togglExportClient.GetRange(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Error in TogglExportClient GetRange: %v", err)
+	}
+	assert.Equal(t, 2, len(togglTimeEntries), "Expected all the time entries of the export")
+	firstEntry := togglTimeEntries[0]
+	assert.Equal(t, "Implement the login page", firstEntry.Description, "Expected the description")
+	assert.Equal(t, time.Date(2021, time.Month(02), 01, 8, 15, 0, 0, time.UTC), firstEntry.Start, "Expected the start in the export time zone")
+	assert.Equal(t, time.Date(2021, time.Month(02), 01, 9, 45, 0, 0, time.UTC), firstEntry.Stop, "Expected the stop in the export time zone")
+	assert.Equal(t, int64(5400), firstEntry.Duration, "Expected the duration in seconds")
+	assert.Equal(t, true, firstEntry.Billable, "Expected a billable time entry")
+	assert.Equal(t, "Website", firstEntry.Project_name, "Expected the project name")
+	assert.Equal(t, "Acme", firstEntry.Client_name, "Expected the client name")
+	assert.Equal(t, "John Doe", firstEntry.User_name, "Expected the user name")
+	assert.Equal(t, []string{"card:aBcD1234", "frontend"}, firstEntry.Tags, "Expected the tags")
+	assert.NotEqual(t, uint64(0), firstEntry.Id&generatedIdBit, "Expected a generated ID")
+	assert.NotEqual(t, firstEntry.Id, togglTimeEntries[1].Id, "Expected different generated IDs")
+
+	togglTimeEntriesAgain, err := togglExportClient.GetRange(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 07, 23, 59, 59, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error in TogglExportClient GetRange: %v", err)
+	}
+	assert.Equal(t, []TogglTimeEntry{firstEntry}, togglTimeEntriesAgain, "Expected the time entries in range with the same generated ID")
+}
+
+func TestTogglExportClientGetRangeFromCsvWithLocaleDateFormat(t *testing.T) {
+	data := "Member;Client;Project;Description;Billable;Start date;Start time;End date;End time;Duration;Tags\n" +
+		"Max Mustermann;Acme;Website;Code review;No;01.02.2021;09:15:00;01.02.2021;09:45:00;0,5;\n"
+	togglExportClient := newTogglExportClient(t, "detailed.csv", data, configuration.TogglConfiguration{ExportDateFormat: "dd.mm.yyyy"})
+
+	togglTimeEntries, err := togglExportClient.GetRange(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Error in TogglExportClient GetRange: %v", err)
+	}
+	assert.Equal(t, 1, len(togglTimeEntries), "Expected the time entry of the export")
+	assert.Equal(t, time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), togglTimeEntries[0].Start, "Expected the start with the configured date format")
+	assert.Equal(t, int64(1800), togglTimeEntries[0].Duration, "Expected the duration from the decimal hours")
+	assert.Equal(t, "Max Mustermann", togglTimeEntries[0].User_name, "Expected the user name from the member column")
+}
+
+func TestTogglExportClientGetRangeThrowsErrorOnMissingColumn(t *testing.T) {
+	togglExportClient := newTogglExportClient(t, "detailed.csv", "User,Description,Start date,Start time\n", configuration.TogglConfiguration{})
+
+	_, err := togglExportClient.GetRange(time.Time{}, time.Time{})
+	if err == nil {
+		t.Fatalf("Expect an error in TogglExportClient GetRange with a missing column")
+	}
+	switch err.(type) {
+	case *InvalidTogglExportError:
+		return
+	default:
+		t.Errorf("Expect an InvalidTogglExportError in TogglExportClient GetRange with a missing column")
+	}
+}
+
+func TestTogglExportClientGetRangeThrowsErrorOnAmbiguousDateFormat(t *testing.T) {
+	data := "User,Description,Start date,Start time,End date,End time,Duration\n" +
+		"John Doe,Code review,02/01/2021,09:15:00,02/01/2021,09:45:00,00:30:00\n"
+	togglExportClient := newTogglExportClient(t, "detailed.csv", data, configuration.TogglConfiguration{})
+
+	_, err := togglExportClient.GetRange(time.Time{}, time.Time{})
+	assert.Equal(t, &InvalidTogglExportError{FileName: togglExportClient.fileName, Message: "the date format is ambiguous between MM/DD/YYYY and DD/MM/YYYY, define the property TOGGL_EXPORT_DATE_FORMAT"}, err, "Expected the ambiguous date format error")
+}
+
+func TestTogglExportClientGetRangeFromJson(t *testing.T) {
+	data := `{"total_count": 1, "data": [{"id": 86854567, "pid": 7458839, "uid": 1234, "description": "Implement the login page",
+		"start": "2021-02-01T10:15:00+01:00", "end": "2021-02-01T10:30:00+01:00", "dur": 900000, "user": "John Doe", "client": "Acme",
+		"project": "Website", "tags": ["frontend"], "is_billable": true}]}`
+	togglExportClient := newTogglExportClient(t, "detailed.json", data, configuration.TogglConfiguration{})
+
+	togglTimeEntries, err := togglExportClient.GetRange(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Error in TogglExportClient GetRange: %v", err)
+	}
+	expectedTogglTimeEntry := TogglTimeEntry{
+		Id:           86854567,
+		Description:  "Implement the login page",
+		Start:        time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC),
+		Stop:         time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC),
+		Duration:     900,
+		Billable:     true,
+		Project_id:   7458839,
+		Project_name: "Website",
+		Client_name:  "Acme",
+		User_id:      1234,
+		User_name:    "John Doe",
+		Tags:         []string{"frontend"},
+	}
+	assert.Equal(t, []TogglTimeEntry{expectedTogglTimeEntry}, togglTimeEntries, "Expected the time entry with the Toggl ID")
+}
+
+func TestTogglStoreFromCsvWithGeneratedIds(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	data := "User,Description,Start date,Start time,End date,End time,Duration\n" +
+		"John Doe,Code review,2021-02-01,09:15:00,2021-02-01,09:45:00,00:30:00\n"
+	togglExportClient := newTogglExportClient(t, "detailed.csv", data, configuration.TogglConfiguration{})
+	togglTimeEntries, err := togglExportClient.GetRange(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Error in TogglExportClient GetRange: %v", err)
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, togglExportClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WithArgs(strconv.FormatUint(togglTimeEntries[0].Id, 10), "Code review", sqlmock.AnyArg(), sqlmock.AnyArg(), 1800, false, 0, "", 0, "", 0, "", 0, "John Doe", sqlmock.AnyArg(), sqlmock.AnyArg(), "", StatusCompleted, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectCommit()
+
+	report, err := togglTime.Store(time.Time{}, time.Time{}, []string{"trello_card_id"})
+	if err != nil {
+		t.Fatalf("Error in TogglTime Store: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected the time entry with the generated ID to be inserted")
+}
@@ -0,0 +1,194 @@
+package toggl
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
+	"go.uber.org/zap"
+)
+
+const togglReportsBaseUrl = "https://api.track.toggl.com/reports/api/v3/"
+
+// reportsPageSize defines the number of rows retrieved with a single request of the detailed report.
+const reportsPageSize = 50
+
+// TogglReportsClient implements the Toggl Client interface with the detailed report of the Toggl Reports API v3.
+// The detailed report contains the time entries of all the workspace members, while the Toggl API v9 returns only the time entries of the API token owner.
+type TogglReportsClient struct {
+	logger        *zap.Logger
+	configuration configuration.TogglConfiguration
+	httpClient    *httpclient.Client
+	baseUrl       string
+	// v9Client resolves the workspaces, the projects, the clients and the tag names with the Toggl API v9.
+	v9Client *TogglV9Client
+}
+
+// ReportsSearchRequest struct defines the request body of the detailed report of the Toggl Reports API v3.
+type ReportsSearchRequest struct {
+	StartDate      string   `json:"start_date"`
+	EndDate        string   `json:"end_date"`
+	UserIds        []uint64 `json:"user_ids,omitempty"`
+	ProjectIds     []uint64 `json:"project_ids,omitempty"`
+	ClientIds      []uint64 `json:"client_ids,omitempty"`
+	PageSize       int      `json:"page_size"`
+	FirstId        uint64   `json:"first_id,omitempty"`
+	FirstRowNumber uint64   `json:"first_row_number,omitempty"`
+}
+
+// ReportsRow struct defines a row of the detailed report of the Toggl Reports API v3, which groups the time entries with the same attributes.
+type ReportsRow struct {
+	UserId      uint64             `json:"user_id"`
+	Username    string             `json:"username"`
+	ProjectId   uint64             `json:"project_id"`
+	Description string             `json:"description"`
+	Billable    bool               `json:"billable"`
+	TagIds      []uint64           `json:"tag_ids"`
+	TimeEntries []ReportsTimeEntry `json:"time_entries"`
+	RowNumber   uint64             `json:"row_number"`
+}
+
+// ReportsTimeEntry struct defines the time entry of a detailed report row.
+type ReportsTimeEntry struct {
+	Id      uint64    `json:"id"`
+	Seconds int64     `json:"seconds"`
+	Start   time.Time `json:"start"`
+	Stop    time.Time `json:"stop"`
+}
+
+// NewTogglReportsClientWithHttpClient creates a new TogglReportsClient that executes the requests with the HTTP client.
+func NewTogglReportsClientWithHttpClient(config configuration.Configuration, logger *zap.Logger, httpClient *httpclient.Client) *TogglReportsClient {
+	return &TogglReportsClient{
+		logger:        logger,
+		configuration: config.TogglConfiguration,
+		httpClient:    httpClient,
+		baseUrl:       baseUrl(config.TogglConfiguration.ReportsBaseUrl, togglReportsBaseUrl),
+		v9Client:      NewTogglV9ClientWithHttpClient(config, logger, httpClient),
+	}
+}
+
+// GetRange retrieves the Toggl Time entries of all the workspace members that start between the startTime and endTime instants.
+// The detailed report is filtered by date in the time zone of the user, so the dates are extended by one day and the time entries are filtered by start time.
+func (togglClient *TogglReportsClient) GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
+	workspaceIds, err := togglClient.v9Client.GetWorkspaceIds()
+	if err != nil {
+		return nil, err
+	}
+	var togglTimeEntries []TogglTimeEntry
+	for _, workspaceId := range workspaceIds {
+		workspaceTimeEntries, err := togglClient.getWorkspaceRange(workspaceId, startTime, endTime)
+		if err != nil {
+			return nil, err
+		}
+		togglTimeEntries = append(togglTimeEntries, workspaceTimeEntries...)
+	}
+	return togglTimeEntries, nil
+}
+
+// ReturnsRunningEntries reports that the Toggl Reports API returns only the completed time entries.
+func (togglClient *TogglReportsClient) ReturnsRunningEntries() bool {
+	return false
+}
+
+// Paginates reports that the Toggl Reports API client retrieves all the pages of the detailed report.
+func (togglClient *TogglReportsClient) Paginates() bool {
+	return true
+}
+
+// getWorkspaceRange retrieves the time entries of the workspace, one page of the detailed report at a time.
+func (togglClient *TogglReportsClient) getWorkspaceRange(workspaceId uint64, startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
+	searchRequest := ReportsSearchRequest{
+		StartDate:  startTime.UTC().AddDate(0, 0, -1).Format("2006-01-02"),
+		EndDate:    endTime.UTC().AddDate(0, 0, 1).Format("2006-01-02"),
+		UserIds:    togglClient.configuration.UserIds,
+		ProjectIds: togglClient.configuration.ProjectIds,
+		ClientIds:  togglClient.configuration.ClientIds,
+		PageSize:   reportsPageSize,
+	}
+	url := togglClient.baseUrl + "workspace/" + strconv.FormatUint(workspaceId, 10) + "/search/time_entries"
+	workspaceName, err := togglClient.v9Client.getWorkspaceName(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	var togglTimeEntries []TogglTimeEntry
+	for {
+		rows, header, err := togglClient.search(url, searchRequest)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			project, err := togglClient.v9Client.getProject(workspaceId, row.ProjectId)
+			if err != nil {
+				return nil, err
+			}
+			clientName, err := togglClient.v9Client.getClientName(workspaceId, project.ClientId)
+			if err != nil {
+				return nil, err
+			}
+			tags, err := togglClient.v9Client.getTags(TimeEntryV9{WorkspaceId: workspaceId, TagIds: row.TagIds})
+			if err != nil {
+				return nil, err
+			}
+			for _, timeEntry := range row.TimeEntries {
+				if timeEntry.Start.Before(startTime) || timeEntry.Start.After(endTime) {
+					continue
+				}
+				togglTimeEntries = append(togglTimeEntries, TogglTimeEntry{
+					Id:             timeEntry.Id,
+					Description:    row.Description,
+					Start:          timeEntry.Start.UTC(),
+					Stop:           timeEntry.Stop.UTC(),
+					Duration:       timeEntry.Seconds,
+					Billable:       row.Billable,
+					Workspace_id:   workspaceId,
+					Workspace_name: workspaceName,
+					Project_id:     row.ProjectId,
+					Project_name:   project.Name,
+					Client_id:      project.ClientId,
+					Client_name:    clientName,
+					User_id:        row.UserId,
+					User_name:      row.Username,
+					Tags:           tags,
+					Tag_ids:        row.TagIds,
+				})
+			}
+		}
+		nextId, nextRowNumber := header.Get("X-Next-ID"), header.Get("X-Next-Row-Number")
+		if nextId == "" || nextRowNumber == "" {
+			break
+		}
+		searchRequest.FirstId, err = strconv.ParseUint(nextId, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		searchRequest.FirstRowNumber, err = strconv.ParseUint(nextRowNumber, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	togglClient.logger.Info("Toggl workspace time entries", zap.Uint64("workspace", workspaceId), zap.Int("count", len(togglTimeEntries)))
+	return togglTimeEntries, nil
+}
+
+// search executes the detailed report request, and returns the report rows with the response header that contains the next page.
+func (togglClient *TogglReportsClient) search(url string, searchRequest ReportsSearchRequest) ([]ReportsRow, http.Header, error) {
+	resp, err := togglClient.httpClient.Post(url, http.Header{"Authorization": {basicAuthorization(togglClient.configuration.ApiToken)}}, searchRequest)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	var rows []ReportsRow
+	err = json.NewDecoder(resp.Body).Decode(&rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, resp.Header, nil
+}
+
+// GetReferenceData retrieves the projects, the clients and the tags of the workspaces with the Toggl API v9.
+func (togglClient *TogglReportsClient) GetReferenceData() (ReferenceData, error) {
+	return togglClient.v9Client.GetReferenceData()
+}
@@ -0,0 +1,133 @@
+package toggl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
+)
+
+func TestTogglReportsClientGetRange(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	var searchRequests []ReportsSearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/me/workspaces":
+			fmt.Fprint(w, `[{"id": 10, "name": "Team"}]`)
+		case "/api/v9/workspaces/10":
+			fmt.Fprint(w, `{"id": 10, "name": "Team"}`)
+		case "/api/v9/workspaces/10/projects":
+			fmt.Fprint(w, `[{"id": 20, "workspace_id": 10, "client_id": 40, "name": "project name"}]`)
+		case "/api/v9/workspaces/10/clients":
+			fmt.Fprint(w, `[{"id": 40, "wid": 10, "name": "client name"}]`)
+		case "/api/v9/workspaces/10/tags":
+			fmt.Fprint(w, `[{"id": 30, "workspace_id": 10, "name": "tag1"}]`)
+		case "/reports/api/v3/workspace/10/search/time_entries":
+			assert.Equal(t, "POST", r.Method, "Expected a POST request")
+			var searchRequest ReportsSearchRequest
+			err := json.NewDecoder(r.Body).Decode(&searchRequest)
+			if err != nil {
+				t.Fatalf("Error decoding the search request: %v", err)
+			}
+			searchRequests = append(searchRequests, searchRequest)
+			if searchRequest.FirstId == 0 {
+				w.Header().Set("X-Next-ID", "3")
+				w.Header().Set("X-Next-Row-Number", "2")
+				fmt.Fprint(w, `[{"user_id": 100, "username": "John Doe", "project_id": 20, "description": "first", "billable": true, "tag_ids": [30], "row_number": 1, "time_entries": [
+					{"id": 1, "seconds": 900, "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z"},
+					{"id": 2, "seconds": 900, "start": "2021-01-31T23:15:00Z", "stop": "2021-01-31T23:30:00Z"}]}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"user_id": 101, "username": "Jane Roe", "project_id": null, "description": "second", "billable": false, "tag_ids": null, "row_number": 2, "time_entries": [
+				{"id": 3, "seconds": 1800, "start": "2021-02-02T10:00:00Z", "stop": "2021-02-02T10:30:00Z"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	config := configuration.Configuration{TogglConfiguration: configuration.TogglConfiguration{
+		ApiToken:       "token",
+		BaseUrl:        server.URL + "/api/v9",
+		ReportsBaseUrl: server.URL + "/reports/api/v3",
+		UserIds:        []uint64{100, 101},
+	}}
+	togglClient := NewTogglReportsClientWithHttpClient(config, logger, httpclient.NewClient(logger, server.Client(), 0, 0))
+
+	togglTimeEntries, err := togglClient.GetRange(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 28, 23, 59, 59, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error in TogglReportsClient GetRange: %v", err)
+	}
+	assert.Equal(t, []TogglTimeEntry{
+		{Id: 1, Description: "first", Start: time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), Stop: time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC),
+			Duration: 900, Billable: true, Workspace_id: 10, Workspace_name: "Team", Project_id: 20, Project_name: "project name", Client_id: 40, Client_name: "client name", User_id: 100, User_name: "John Doe", Tags: []string{"tag1"}, Tag_ids: []uint64{30}},
+		{Id: 3, Description: "second", Start: time.Date(2021, time.Month(02), 02, 10, 0, 0, 0, time.UTC), Stop: time.Date(2021, time.Month(02), 02, 10, 30, 0, 0, time.UTC),
+			Duration: 1800, Workspace_id: 10, Workspace_name: "Team", User_id: 101, User_name: "Jane Roe"},
+	}, togglTimeEntries, "Expected the time entries of all the pages in the range")
+	assert.Equal(t, 2, len(searchRequests), "Expected two pages of the detailed report")
+	assert.Equal(t, ReportsSearchRequest{StartDate: "2021-01-31", EndDate: "2021-03-01", UserIds: []uint64{100, 101}, PageSize: reportsPageSize}, searchRequests[0], "Expected the first page request with the user filter")
+	assert.Equal(t, uint64(3), searchRequests[1].FirstId, "Expected the next ID of the second page")
+	assert.Equal(t, uint64(2), searchRequests[1].FirstRowNumber, "Expected the next row number of the second page")
+}
+
+func TestNewClientReportsGetRangeBeyondMaxEntriesPerRequest(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	searchRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/10":
+			fmt.Fprint(w, `{"id": 10, "name": "Team"}`)
+		case "/reports/api/v3/workspace/10/search/time_entries":
+			searchRequests++
+			var searchRequest ReportsSearchRequest
+			err := json.NewDecoder(r.Body).Decode(&searchRequest)
+			if err != nil {
+				t.Fatalf("Error decoding the search request: %v", err)
+			}
+			if searchRequest.FirstId == 0 {
+				w.Header().Set("X-Next-ID", "3")
+				w.Header().Set("X-Next-Row-Number", "2")
+				fmt.Fprint(w, `[{"user_id": 100, "username": "John Doe", "description": "first", "row_number": 1, "time_entries": [
+					{"id": 1, "seconds": 900, "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z"},
+					{"id": 2, "seconds": 900, "start": "2021-02-01T10:15:00Z", "stop": "2021-02-01T10:30:00Z"}]}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"user_id": 101, "username": "Jane Roe", "description": "second", "row_number": 2, "time_entries": [
+				{"id": 3, "seconds": 1800, "start": "2021-02-02T10:00:00Z", "stop": "2021-02-02T10:30:00Z"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	config := configuration.Configuration{TogglConfiguration: configuration.TogglConfiguration{
+		ApiVersion:           "reports",
+		ApiToken:             "token",
+		BaseUrl:              server.URL + "/api/v9",
+		ReportsBaseUrl:       server.URL + "/reports/api/v3",
+		WorkspaceIds:         []uint64{10},
+		MaxEntriesPerRequest: 2,
+		RunningEntries:       configuration.RunningEntriesProvisional,
+	}}
+	togglClient, err := NewClient(config, logger, httpclient.NewClient(logger, server.Client(), 0, 0))
+	if err != nil {
+		t.Fatalf("Error creating the Toggl client: %v", err)
+	}
+
+	togglTimeEntries, err := togglClient.GetRange(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 28, 23, 59, 59, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error in Client GetRange: %v", err)
+	}
+	assert.Equal(t, 3, len(togglTimeEntries), "Expected the time entries of all the pages")
+	assert.Equal(t, 2, searchRequests, "Expected one request per page, without splitting the window at the cap")
+}
@@ -1,7 +1,10 @@
 package toggl
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -10,10 +13,15 @@ import (
 	"github.com/bmizerany/assert"
 	"github.com/lib/pq"
 	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
+var runningEntryColumns = []string{"id", "start", "workspace_id", "user_id"}
+
 type MockTogglClient struct {
 	mock.Mock
 	emptyTogglEntries bool
@@ -32,9 +40,15 @@ func (mockTogglClient *MockTogglClient) GetRange(start time.Time, end time.Time)
 		Duration:       900,
 		Billable:       true,
 		Workspace_id:   2245503,
+		Workspace_name: "workspace name",
 		Project_id:     7458839,
 		Project_name:   "project name",
+		Client_id:      3311,
+		Client_name:    "client name",
+		User_id:        1234,
+		User_name:      "John Doe",
 		Tags:           []string{"tag1"},
+		Tag_ids:        []uint64{1001},
 		Trello_card_id: "",
 	}
 	togglTimeEntries = append(togglTimeEntries, togglTimeEntry)
@@ -148,8 +162,8 @@ func TestTogglDownloadAsCsv(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error while reading the file %s: %v", togglTimeEntriesFileName, err)
 	}
-	expectedData := `Id,Description,Start,Stop,Duration,Billable,Workspace_id,Project_id,Project_name,Tags,Trello_card_id
-86854567,description,2021-02-01T09:15:00Z,2021-02-01T09:30:00Z,900,true,2245503,7458839,project name,tag1,
+	expectedData := `Id,Description,Start,Stop,Duration,Billable,Workspace_id,Workspace_name,Project_id,Project_name,Client_id,Client_name,User_id,User_name,Tags,Tag_ids,Trello_card_id,Status
+86854567,description,2021-02-01T09:15:00Z,2021-02-01T09:30:00Z,900,true,2245503,workspace name,7458839,project name,3311,client name,1234,John Doe,tag1,1001,,completed
 `
 	assert.Equal(t, []byte(expectedData), data, "Expected same file content")
 }
@@ -200,16 +214,290 @@ func TestTogglStoreInDatabase(t *testing.T) {
 	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
 	endTime := time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC)
 
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns))
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO toggl_time").
-		WithArgs(86854567, "description", time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC), 900, true, 2245503, 7458839, "project name", pq.Array([]string{"tag1"})).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WithArgs("86854567", "description", time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC), 900, true, 2245503, "workspace name", 7458839, "project name", 3311, "client name", 1234, "John Doe", pq.Array([]string{"tag1"}), pq.Int64Array{1001}, "", StatusCompleted, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
 	mock.ExpectCommit()
 
-	err = togglTime.Store(startTime, endTime)
+	report, err := togglTime.Store(startTime, endTime, []string{"trello_card_id"})
 	if err != nil {
 		t.Errorf("Error in TogglTime Store: %v", err)
 	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected one inserted time entry")
+}
+
+func TestTogglStoreReconcilesRunningEntries(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	togglClient := &rangeRecordingClient{returnsRunningEntries: true, entries: func(startTime time.Time, endTime time.Time) []TogglTimeEntry {
+		return []TogglTimeEntry{{Id: 86854567, Start: time.Date(2021, time.Month(01), 31, 23, 0, 0, 0, time.UTC), Stop: time.Date(2021, time.Month(02), 01, 0, 30, 0, 0, time.UTC), Duration: 5400, Workspace_id: 2245503, User_id: 1234}}
+	}}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, togglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns).
+			AddRow("86854567", time.Date(2021, time.Month(01), 31, 23, 0, 0, 0, time.UTC), 2245503, 1234).
+			AddRow("86854568", time.Date(2021, time.Month(02), 01, 8, 0, 0, 0, time.UTC), 2245503, 1234).
+			AddRow("86854569", time.Date(2021, time.Month(02), 01, 8, 0, 0, 0, time.UTC), 2245503, 5678).
+			AddRow("86854570", time.Date(2021, time.Month(02), 01, 8, 0, 0, 0, time.UTC), 2245504, 1234))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+	mock.ExpectExec("DELETE FROM toggl_time").
+		WithArgs(pq.Array([]string{"86854568"}), StatusRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	report, err := togglTime.Store(startTime, endTime, []string{"trello_card_id"})
+	if err != nil {
+		t.Errorf("Error in TogglTime Store: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Updated: 1}, report, "Expected the running time entry to be updated")
+	assert.Equal(t, []window{{startTime: time.Date(2021, time.Month(01), 31, 23, 0, 0, 0, time.UTC), endTime: endTime}}, togglClient.windows, "Expected the range extended to the start of the running time entry")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect only the discarded running time entry of the retrieved workspace and user to be deleted: %v", err)
+	}
+}
+
+func TestTogglStoreKeepsRunningEntriesWithReportsClient(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/workspaces/10":
+			fmt.Fprint(w, `{"id": 10, "name": "Team"}`)
+		case "/api/v9/workspaces/10/projects", "/api/v9/workspaces/10/clients", "/api/v9/workspaces/10/tags":
+			fmt.Fprint(w, `[]`)
+		case "/reports/api/v3/workspace/10/search/time_entries":
+			fmt.Fprint(w, `[{"user_id": 100, "username": "John Doe", "description": "completed", "row_number": 1, "time_entries": [
+				{"id": 1, "seconds": 900, "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	config := configuration.Configuration{TogglConfiguration: configuration.TogglConfiguration{
+		ApiVersion:     "reports",
+		BaseUrl:        server.URL + "/api/v9",
+		ReportsBaseUrl: server.URL + "/reports/api/v3",
+		WorkspaceIds:   []uint64{10},
+		RunningEntries: configuration.RunningEntriesProvisional,
+	}}
+	togglClient, err := NewClient(config, logger, httpclient.NewClient(logger, server.Client(), 0, 0))
+	if err != nil {
+		t.Fatalf("Error creating the Toggl client: %v", err)
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, togglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns).
+			AddRow("2", time.Date(2021, time.Month(02), 01, 10, 0, 0, 0, time.UTC), 10, 100))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectCommit()
+
+	report, err := togglTime.Store(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 28, 23, 59, 59, 0, time.UTC), []string{"trello_card_id"})
+	if err != nil {
+		t.Errorf("Error in TogglTime Store: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected the completed time entry to be inserted")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the running time entry to be kept with the Toggl Reports API: %v", err)
+	}
+}
+
+func TestTogglStoreKeepsRunningEntriesWithExportClient(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	data := "User,Description,Start date,Start time,End date,End time,Duration\n" +
+		"John Doe,Code review,2021-02-01,09:15:00,2021-02-01,09:45:00,00:30:00\n"
+	togglClient := newTogglExportClient(t, "detailed.csv", data, configuration.TogglConfiguration{})
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, togglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns).
+			AddRow("86854568", time.Date(2021, time.Month(02), 01, 8, 0, 0, 0, time.UTC), 0, 0))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectCommit()
+
+	report, err := togglTime.Store(time.Time{}, time.Time{}, []string{"trello_card_id"})
+	if err != nil {
+		t.Errorf("Error in TogglTime Store: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected the time entry of the export to be inserted")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the running time entry to be kept with the export: %v", err)
+	}
+}
+
+func TestTogglStoreInDatabaseUnchangedEntry(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	mockTogglClient := &MockTogglClient{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, mockTogglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time(.+) ON CONFLICT \\(id\\) DO UPDATE SET (.+) WHERE").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}))
+	mock.ExpectCommit()
+
+	report, err := togglTime.Store(startTime, endTime, []string{"trello_card_id"})
+	if err != nil {
+		t.Errorf("Error in TogglTime Store: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Unchanged: 1}, report, "Expected one unchanged time entry")
+}
+
+func TestTogglStoreInDatabaseRollsBackOnError(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	mockTogglClient := &MockTogglClient{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, mockTogglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	startTime := time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WillReturnError(fmt.Errorf("connection lost"))
+	mock.ExpectRollback()
+
+	report, err := togglTime.Store(startTime, endTime, []string{"trello_card_id"})
+	if err == nil {
+		t.Errorf("Expect an error in TogglTime Store when the insert fails")
+	}
+	assert.Equal(t, storage.SyncReport{}, report, "Expected an empty report")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect the transaction to be rolled back: %v", err)
+	}
+}
+
+func TestTogglStoreThrowsUnknownColumnErrorOnInvalidPreservedColumn(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	mockTogglClient := &MockTogglClient{}
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, mockTogglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	_, err = togglTime.Store(time.Now(), time.Now(), []string{"unknown"})
+	switch err.(type) {
+	case *storage.UnknownColumnError:
+		return
+	default:
+		t.Errorf("Expect an UnknownColumnError in TogglTime Store with an invalid preserved column")
+	}
+}
+
+func TestTogglLastEntryStart(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	mockTogglClient := &MockTogglClient{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, mockTogglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+	lastStart := time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT max\\(start\\) FROM toggl_time").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(lastStart))
+
+	lastEntryStart, err := togglTime.LastEntryStart()
+	if err != nil {
+		t.Fatalf("Error in TogglTime LastEntryStart: %v", err)
+	}
+	assert.Equal(t, lastStart, lastEntryStart, "Expected the last time entry start")
 }
 
 func getLogger() (*zap.Logger, error) {
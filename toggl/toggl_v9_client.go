@@ -0,0 +1,421 @@
+package toggl
+
+import (
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
+	"go.uber.org/zap"
+)
+
+const togglV9BaseUrl = "https://api.track.toggl.com/api/v9/"
+
+// projectsPageSize defines the number of projects retrieved with a single request, which is the maximum allowed by the Toggl API v9.
+const projectsPageSize = 200
+
+// TogglV9Client implements the Toggl Client interface with the Toggl API v9.
+type TogglV9Client struct {
+	logger        *zap.Logger
+	configuration configuration.TogglConfiguration
+	httpClient    *httpclient.Client
+	baseUrl       string
+	// projects caches the projects by workspace ID and project ID.
+	projects map[uint64]map[uint64]ProjectV9
+	// clientNames caches the client names by workspace ID and client ID.
+	clientNames map[uint64]map[uint64]string
+	// workspaceNames caches the workspace names by workspace ID.
+	workspaceNames map[uint64]string
+	// tagNames caches the tag names by workspace ID and tag ID.
+	tagNames map[uint64]map[uint64]string
+	// workspaceIds caches the workspaces of the user when not configured.
+	workspaceIds []uint64
+	// cacheMutex protects the caches, since the time entries can be retrieved concurrently.
+	cacheMutex sync.Mutex
+}
+
+// TimeEntryV9 struct defines the Toggl Time entry of the Toggl API v9.
+type TimeEntryV9 struct {
+	Id          uint64    `json:"id"`
+	Description string    `json:"description"`
+	Start       time.Time `json:"start"`
+	Stop        time.Time `json:"stop"`
+	Duration    int64     `json:"duration"`
+	Billable    bool      `json:"billable"`
+	WorkspaceId uint64    `json:"workspace_id"`
+	ProjectId   uint64    `json:"project_id"`
+	Tags        []string  `json:"tags"`
+	TagIds      []uint64  `json:"tag_ids"`
+	// ServerDeletedAt is set on the time entries deleted in Toggl, which are returned only by the requests with the "since" parameter.
+	ServerDeletedAt *time.Time `json:"server_deleted_at"`
+}
+
+// ProjectV9 struct defines the Project entry of the Toggl API v9.
+type ProjectV9 struct {
+	Id          uint64 `json:"id"`
+	WorkspaceId uint64 `json:"workspace_id"`
+	ClientId    uint64 `json:"client_id"`
+	Name        string `json:"name"`
+	Billable    bool   `json:"billable"`
+	IsPrivate   bool   `json:"is_private"`
+	Active      bool   `json:"active"`
+	Color       string `json:"color"`
+}
+
+// ClientV9 struct defines the Client entry of the Toggl API v9.
+type ClientV9 struct {
+	Id          uint64 `json:"id"`
+	WorkspaceId uint64 `json:"wid"`
+	Name        string `json:"name"`
+	Archived    bool   `json:"archived"`
+}
+
+// WorkspaceV9 struct defines the Workspace entry of the Toggl API v9.
+type WorkspaceV9 struct {
+	Id   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+// TagV9 struct defines the Tag entry of the Toggl API v9.
+type TagV9 struct {
+	Id          uint64 `json:"id"`
+	WorkspaceId uint64 `json:"workspace_id"`
+	Name        string `json:"name"`
+}
+
+// NewTogglV9Client creates a new TogglV9Client.
+func NewTogglV9Client(config configuration.Configuration, logger *zap.Logger) *TogglV9Client {
+	return NewTogglV9ClientWithHttpClient(config, logger, newDefaultHttpClient(config, logger))
+}
+
+// NewTogglV9ClientWithHttpClient creates a new TogglV9Client that executes the requests with the HTTP client.
+func NewTogglV9ClientWithHttpClient(config configuration.Configuration, logger *zap.Logger, httpClient *httpclient.Client) *TogglV9Client {
+	return &TogglV9Client{
+		logger:         logger,
+		configuration:  config.TogglConfiguration,
+		httpClient:     httpClient,
+		baseUrl:        baseUrl(config.TogglConfiguration.BaseUrl, togglV9BaseUrl),
+		projects:       make(map[uint64]map[uint64]ProjectV9),
+		clientNames:    make(map[uint64]map[uint64]string),
+		workspaceNames: make(map[uint64]string),
+		tagNames:       make(map[uint64]map[uint64]string),
+	}
+}
+
+// GetRange retrieves the Toggl Time entries that start between the startTime and endTime instants.
+func (togglClient *TogglV9Client) GetRange(startTime time.Time, endTime time.Time) ([]TogglTimeEntry, error) {
+	url := togglClient.baseUrl + "me/time_entries?start_date=" + neturl.QueryEscape(startTime.Format(time.RFC3339)) + "&end_date=" + neturl.QueryEscape(endTime.Format(time.RFC3339))
+	var timeEntries []TimeEntryV9
+	err := togglClient.get(url, &timeEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	var togglTimeEntries = make([]TogglTimeEntry, len(timeEntries))
+	for i, timeEntry := range timeEntries {
+		togglTimeEntries[i], err = togglClient.newTogglTimeEntry(timeEntry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return togglTimeEntries, nil
+}
+
+// ReturnsRunningEntries reports that the Toggl API v9 returns the running time entries.
+func (togglClient *TogglV9Client) ReturnsRunningEntries() bool {
+	return true
+}
+
+// GetSince retrieves the Toggl Time entries of the workspaces created, updated or deleted after the since instant of each workspace.
+// The time entries of all the workspaces are retrieved with a single request since the earliest instant, and the time entries of the
+// workspaces not in the since map are skipped. The time entries deleted in Toggl are returned as IDs.
+func (togglClient *TogglV9Client) GetSince(since map[uint64]time.Time) ([]TogglTimeEntry, []uint64, error) {
+	if len(since) == 0 {
+		return nil, nil, nil
+	}
+	var earliest time.Time
+	for _, workspaceSince := range since {
+		if earliest.IsZero() || workspaceSince.Before(earliest) {
+			earliest = workspaceSince
+		}
+	}
+	url := togglClient.baseUrl + "me/time_entries?since=" + strconv.FormatInt(earliest.Unix(), 10)
+	var timeEntries []TimeEntryV9
+	err := togglClient.get(url, &timeEntries)
+	if err != nil {
+		return nil, nil, err
+	}
+	var togglTimeEntries []TogglTimeEntry
+	var deletedIds []uint64
+	for _, timeEntry := range timeEntries {
+		if _, found := since[timeEntry.WorkspaceId]; !found {
+			continue
+		}
+		if timeEntry.ServerDeletedAt != nil {
+			deletedIds = append(deletedIds, timeEntry.Id)
+			continue
+		}
+		togglTimeEntry, err := togglClient.newTogglTimeEntry(timeEntry)
+		if err != nil {
+			return nil, nil, err
+		}
+		togglTimeEntries = append(togglTimeEntries, togglTimeEntry)
+	}
+	togglClient.logger.Info("Toggl modified time entries", zap.Int("workspaces", len(since)), zap.Int("count", len(togglTimeEntries)), zap.Int("deleted", len(deletedIds)))
+	return togglTimeEntries, deletedIds, nil
+}
+
+// newTogglTimeEntry creates the Toggl time entry with the names of the workspace, the project, the client and the tags.
+func (togglClient *TogglV9Client) newTogglTimeEntry(timeEntry TimeEntryV9) (TogglTimeEntry, error) {
+	workspaceName, err := togglClient.getWorkspaceName(timeEntry.WorkspaceId)
+	if err != nil {
+		return TogglTimeEntry{}, err
+	}
+	project, err := togglClient.getProject(timeEntry.WorkspaceId, timeEntry.ProjectId)
+	if err != nil {
+		return TogglTimeEntry{}, err
+	}
+	clientName, err := togglClient.getClientName(timeEntry.WorkspaceId, project.ClientId)
+	if err != nil {
+		return TogglTimeEntry{}, err
+	}
+	tags, err := togglClient.getTags(timeEntry)
+	if err != nil {
+		return TogglTimeEntry{}, err
+	}
+	return TogglTimeEntry{
+		Id:             timeEntry.Id,
+		Description:    timeEntry.Description,
+		Start:          timeEntry.Start.UTC(),
+		Stop:           timeEntry.Stop.UTC(),
+		Duration:       timeEntry.Duration,
+		Billable:       timeEntry.Billable,
+		Workspace_id:   timeEntry.WorkspaceId,
+		Workspace_name: workspaceName,
+		Project_id:     timeEntry.ProjectId,
+		Project_name:   project.Name,
+		Client_id:      project.ClientId,
+		Client_name:    clientName,
+		Tags:           tags,
+		Tag_ids:        timeEntry.TagIds,
+		Trello_card_id: "",
+	}, nil
+}
+
+// getProject retrieves the Project from the workspace projects, which are retrieved once per workspace.
+// The projects missing from the workspace list, e.g. the archived projects, are retrieved one by one.
+func (togglClient *TogglV9Client) getProject(workspaceId uint64, projectId uint64) (ProjectV9, error) {
+	if projectId == 0 {
+		return ProjectV9{}, nil
+	}
+	togglClient.cacheMutex.Lock()
+	defer togglClient.cacheMutex.Unlock()
+	projects, found := togglClient.projects[workspaceId]
+	if !found {
+		var workspaceProjects []ProjectV9
+		err := togglClient.get(togglClient.workspaceUrl(workspaceId)+"/projects", &workspaceProjects)
+		if err != nil {
+			return ProjectV9{}, err
+		}
+		projects = make(map[uint64]ProjectV9)
+		for _, project := range workspaceProjects {
+			projects[project.Id] = project
+		}
+		togglClient.projects[workspaceId] = projects
+	}
+	if project, found := projects[projectId]; found {
+		return project, nil
+	}
+	var project ProjectV9
+	err := togglClient.get(togglClient.workspaceUrl(workspaceId)+"/projects/"+strconv.FormatUint(projectId, 10), &project)
+	if err != nil {
+		return ProjectV9{}, err
+	}
+	projects[projectId] = project
+	return project, nil
+}
+
+// getClientName retrieves the Client Name from the workspace clients, which are retrieved once per workspace.
+// The clients missing from the workspace list, e.g. the archived clients, are retrieved one by one.
+func (togglClient *TogglV9Client) getClientName(workspaceId uint64, clientId uint64) (string, error) {
+	if clientId == 0 {
+		return "", nil
+	}
+	togglClient.cacheMutex.Lock()
+	defer togglClient.cacheMutex.Unlock()
+	clientNames, found := togglClient.clientNames[workspaceId]
+	if !found {
+		var clients []ClientV9
+		err := togglClient.get(togglClient.workspaceUrl(workspaceId)+"/clients", &clients)
+		if err != nil {
+			return "", err
+		}
+		clientNames = make(map[uint64]string)
+		for _, client := range clients {
+			clientNames[client.Id] = client.Name
+		}
+		togglClient.clientNames[workspaceId] = clientNames
+	}
+	if clientName, found := clientNames[clientId]; found {
+		return clientName, nil
+	}
+	var client ClientV9
+	err := togglClient.get(togglClient.workspaceUrl(workspaceId)+"/clients/"+strconv.FormatUint(clientId, 10), &client)
+	if err != nil {
+		return "", err
+	}
+	clientNames[clientId] = client.Name
+	return client.Name, nil
+}
+
+// getWorkspaceName retrieves the Workspace Name, which is retrieved once per workspace.
+func (togglClient *TogglV9Client) getWorkspaceName(workspaceId uint64) (string, error) {
+	if workspaceId == 0 {
+		return "", nil
+	}
+	togglClient.cacheMutex.Lock()
+	defer togglClient.cacheMutex.Unlock()
+	if workspaceName, found := togglClient.workspaceNames[workspaceId]; found {
+		return workspaceName, nil
+	}
+	var workspace WorkspaceV9
+	err := togglClient.get(togglClient.workspaceUrl(workspaceId), &workspace)
+	if err != nil {
+		return "", err
+	}
+	togglClient.workspaceNames[workspaceId] = workspace.Name
+	return workspace.Name, nil
+}
+
+// getTags retrieves the tag names of the time entry. The tag names are resolved from the tag IDs when missing.
+func (togglClient *TogglV9Client) getTags(timeEntry TimeEntryV9) ([]string, error) {
+	if len(timeEntry.Tags) > 0 || len(timeEntry.TagIds) == 0 {
+		return timeEntry.Tags, nil
+	}
+	togglClient.cacheMutex.Lock()
+	defer togglClient.cacheMutex.Unlock()
+	tagNames, found := togglClient.tagNames[timeEntry.WorkspaceId]
+	if !found {
+		var tags []TagV9
+		err := togglClient.get(togglClient.workspaceUrl(timeEntry.WorkspaceId)+"/tags", &tags)
+		if err != nil {
+			return nil, err
+		}
+		tagNames = make(map[uint64]string)
+		for _, tag := range tags {
+			tagNames[tag.Id] = tag.Name
+		}
+		togglClient.tagNames[timeEntry.WorkspaceId] = tagNames
+	}
+	var tags []string
+	for _, tagId := range timeEntry.TagIds {
+		if tagName, found := tagNames[tagId]; found {
+			tags = append(tags, tagName)
+		}
+	}
+	return tags, nil
+}
+
+// GetReferenceData retrieves the projects, the clients and the tags of the configured workspaces, or of all the workspaces of the user.
+// The archived clients are retrieved as well, since the stored time entries may reference them.
+func (togglClient *TogglV9Client) GetReferenceData() (ReferenceData, error) {
+	workspaceIds, err := togglClient.GetWorkspaceIds()
+	if err != nil {
+		return ReferenceData{}, err
+	}
+	var referenceData ReferenceData
+	for _, workspaceId := range workspaceIds {
+		projects, err := togglClient.getWorkspaceProjects(workspaceId)
+		if err != nil {
+			return ReferenceData{}, err
+		}
+		for _, project := range projects {
+			referenceData.Projects = append(referenceData.Projects, TogglProjectEntry{
+				Id:           project.Id,
+				Workspace_id: workspaceId,
+				Client_id:    project.ClientId,
+				Name:         project.Name,
+				Billable:     project.Billable,
+				Active:       project.Active,
+			})
+		}
+		var clients []ClientV9
+		err = togglClient.get(togglClient.workspaceUrl(workspaceId)+"/clients?status=both", &clients)
+		if err != nil {
+			return ReferenceData{}, err
+		}
+		for _, client := range clients {
+			referenceData.Clients = append(referenceData.Clients, TogglClientEntry{
+				Id:           client.Id,
+				Workspace_id: workspaceId,
+				Name:         client.Name,
+				Archived:     client.Archived,
+			})
+		}
+		var tags []TagV9
+		err = togglClient.get(togglClient.workspaceUrl(workspaceId)+"/tags", &tags)
+		if err != nil {
+			return ReferenceData{}, err
+		}
+		for _, tag := range tags {
+			referenceData.Tags = append(referenceData.Tags, TogglTagEntry{
+				Id:           tag.Id,
+				Workspace_id: workspaceId,
+				Name:         tag.Name,
+			})
+		}
+	}
+	return referenceData, nil
+}
+
+// getWorkspaceProjects retrieves all the projects of the workspace, one page at a time.
+func (togglClient *TogglV9Client) getWorkspaceProjects(workspaceId uint64) ([]ProjectV9, error) {
+	var projects []ProjectV9
+	for page := 1; ; page++ {
+		var pageProjects []ProjectV9
+		err := togglClient.get(togglClient.workspaceUrl(workspaceId)+"/projects?page="+strconv.Itoa(page)+"&per_page="+strconv.Itoa(projectsPageSize), &pageProjects)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, pageProjects...)
+		if len(pageProjects) < projectsPageSize {
+			return projects, nil
+		}
+	}
+}
+
+// GetWorkspaceIds returns the configured workspaces, or retrieves the workspaces of the user once.
+func (togglClient *TogglV9Client) GetWorkspaceIds() ([]uint64, error) {
+	if len(togglClient.configuration.WorkspaceIds) > 0 {
+		return togglClient.configuration.WorkspaceIds, nil
+	}
+	togglClient.cacheMutex.Lock()
+	defer togglClient.cacheMutex.Unlock()
+	if togglClient.workspaceIds != nil {
+		return togglClient.workspaceIds, nil
+	}
+	var workspaces []WorkspaceV9
+	err := togglClient.get(togglClient.baseUrl+"me/workspaces", &workspaces)
+	if err != nil {
+		return nil, err
+	}
+	workspaceIds := make([]uint64, len(workspaces))
+	for i, workspace := range workspaces {
+		workspaceIds[i] = workspace.Id
+		togglClient.workspaceNames[workspace.Id] = workspace.Name
+	}
+	togglClient.workspaceIds = workspaceIds
+	return workspaceIds, nil
+}
+
+func (togglClient *TogglV9Client) workspaceUrl(workspaceId uint64) string {
+	return togglClient.baseUrl + "workspaces/" + strconv.FormatUint(workspaceId, 10)
+}
+
+// get executes the Http GET call from a URL and decodes the JSON response body into the result.
+func (togglClient *TogglV9Client) get(url string, result interface{}) error {
+	return togglClient.httpClient.GetJson(url, http.Header{"Authorization": {basicAuthorization(togglClient.configuration.ApiToken)}}, result)
+}
@@ -0,0 +1,192 @@
+package toggl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bmizerany/assert"
+	"github.com/lib/pq"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/httpclient"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+)
+
+func newTestTogglV9Client(t *testing.T, handler http.HandlerFunc) (*TogglV9Client, *httptest.Server) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	togglClient := NewTogglV9Client(configuration.Configuration{TogglConfiguration: configuration.TogglConfiguration{ApiToken: "token", BaseUrl: server.URL + "/api/v9"}}, logger)
+	return togglClient, server
+}
+
+func TestTogglV9ClientGetRange(t *testing.T) {
+	projectRequests, clientRequests, workspaceRequests := 0, 0, 0
+	togglClient, server := newTestTogglV9Client(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/me/time_entries":
+			assert.Equal(t, "2021-02-01T00:00:00Z", r.URL.Query().Get("start_date"), "Expected the start date")
+			fmt.Fprint(w, `[
+				{"id": 1, "description": "first", "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z", "duration": 900, "billable": true, "workspace_id": 10, "project_id": 20, "tags": ["tag1"], "tag_ids": [30]},
+				{"id": 2, "description": "second", "start": "2021-02-02T09:15:00Z", "stop": "2021-02-02T09:30:00Z", "duration": 900, "billable": false, "workspace_id": 10, "project_id": 20, "tags": null, "tag_ids": null},
+				{"id": 3, "description": "third", "start": "2021-02-03T09:15:00Z", "stop": "2021-02-03T09:30:00Z", "duration": 900, "billable": false, "workspace_id": 10, "project_id": null, "tags": null, "tag_ids": null}
+			]`)
+		case "/api/v9/workspaces/10/projects":
+			projectRequests++
+			fmt.Fprint(w, `[{"id": 20, "workspace_id": 10, "client_id": 40, "name": "project name"}]`)
+		case "/api/v9/workspaces/10/clients":
+			clientRequests++
+			fmt.Fprint(w, `[{"id": 40, "wid": 10, "name": "client name"}]`)
+		case "/api/v9/workspaces/10":
+			workspaceRequests++
+			fmt.Fprint(w, `{"id": 10, "name": "workspace name"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	togglTimeEntries, err := togglClient.GetRange(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 28, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Error in TogglV9Client GetRange: %v", err)
+	}
+	assert.Equal(t, 3, len(togglTimeEntries), "Expected three time entries")
+	assert.Equal(t, TogglTimeEntry{
+		Id:             1,
+		Description:    "first",
+		Start:          time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC),
+		Stop:           time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC),
+		Duration:       900,
+		Billable:       true,
+		Workspace_id:   10,
+		Workspace_name: "workspace name",
+		Project_id:     20,
+		Project_name:   "project name",
+		Client_id:      40,
+		Client_name:    "client name",
+		Tags:           []string{"tag1"},
+		Tag_ids:        []uint64{30},
+	}, togglTimeEntries[0], "Expected the first time entry")
+	assert.Equal(t, "", togglTimeEntries[2].Project_name, "Expected no project name for the time entry without project")
+	assert.Equal(t, "", togglTimeEntries[2].Client_name, "Expected no client name for the time entry without project")
+	assert.Equal(t, 1, projectRequests, "Expected the workspace projects to be retrieved once")
+	assert.Equal(t, 1, clientRequests, "Expected the workspace clients to be retrieved once")
+	assert.Equal(t, 1, workspaceRequests, "Expected the workspace to be retrieved once")
+}
+
+func TestTogglV9ClientGetRangeResolvesTagIds(t *testing.T) {
+	togglClient, server := newTestTogglV9Client(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/me/time_entries":
+			fmt.Fprint(w, `[{"id": 1, "description": "first", "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z", "duration": 900, "workspace_id": 10, "tag_ids": [31, 30]}]`)
+		case "/api/v9/workspaces/10":
+			fmt.Fprint(w, `{"id": 10, "name": "workspace name"}`)
+		case "/api/v9/workspaces/10/tags":
+			fmt.Fprint(w, `[{"id": 30, "workspace_id": 10, "name": "tag1"}, {"id": 31, "workspace_id": 10, "name": "card:aBcD1234"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	togglTimeEntries, err := togglClient.GetRange(time.Now(), time.Now())
+	if err != nil {
+		t.Fatalf("Error in TogglV9Client GetRange: %v", err)
+	}
+	assert.Equal(t, []string{"card:aBcD1234", "tag1"}, togglTimeEntries[0].Tags, "Expected the tag names")
+}
+
+func TestTogglV9ClientGetRangeRetrievesArchivedProject(t *testing.T) {
+	togglClient, server := newTestTogglV9Client(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v9/me/time_entries":
+			fmt.Fprint(w, `[{"id": 1, "description": "first", "start": "2021-02-01T09:15:00Z", "stop": "2021-02-01T09:30:00Z", "duration": 900, "workspace_id": 10, "project_id": 21}]`)
+		case "/api/v9/workspaces/10":
+			fmt.Fprint(w, `{"id": 10, "name": "workspace name"}`)
+		case "/api/v9/workspaces/10/projects":
+			fmt.Fprint(w, `[]`)
+		case "/api/v9/workspaces/10/projects/21":
+			fmt.Fprint(w, `{"id": 21, "workspace_id": 10, "name": "archived project", "active": false}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer server.Close()
+
+	togglTimeEntries, err := togglClient.GetRange(time.Now(), time.Now())
+	if err != nil {
+		t.Fatalf("Error in TogglV9Client GetRange: %v", err)
+	}
+	assert.Equal(t, "archived project", togglTimeEntries[0].Project_name, "Expected the archived project name")
+}
+
+func TestTogglV9ClientGetRangeThrowsStatusErrorOnUnauthorized(t *testing.T) {
+	togglClient, server := newTestTogglV9Client(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer server.Close()
+
+	_, err := togglClient.GetRange(time.Now(), time.Now())
+	switch err := err.(type) {
+	case *httpclient.StatusError:
+		assert.Equal(t, http.StatusUnauthorized, err.StatusCode, "Expected the response status code")
+	default:
+		t.Errorf("Expect a StatusError in TogglV9Client GetRange with an invalid API token, got %v", err)
+	}
+}
+
+func TestTogglStoreReplaysRecordedResponses(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	transport, err := httpclient.NewRecordReplayTransport(httpclient.Replay, "testdata/fixtures", nil)
+	if err != nil {
+		t.Fatalf("Error creating RecordReplayTransport: %v", err)
+	}
+	togglClient := NewTogglV9ClientWithHttpClient(configuration.Configuration{}, logger, httpclient.NewClient(logger, &http.Client{Transport: transport}, 0, 0))
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	togglTime, err := NewTogglTimeWithDatabaseConnection(logger, togglClient, db)
+	if err != nil {
+		t.Fatalf("Error creating TogglTime: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT id, start, workspace_id, user_id FROM toggl_time WHERE status").
+		WithArgs(StatusRunning).
+		WillReturnRows(sqlmock.NewRows(runningEntryColumns))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO toggl_time").
+		WithArgs("86854567", "description", time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC), time.Date(2021, time.Month(02), 01, 9, 30, 0, 0, time.UTC), 900, true, 2245503, "Toggl workspace", 7458839, "project name", 0, "", 0, "", pq.Array([]string{"tag1"}), pq.Int64Array{1001}, "", StatusCompleted, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectCommit()
+
+	report, err := togglTime.Store(time.Date(2021, time.Month(02), 01, 0, 0, 0, 0, time.UTC), time.Date(2021, time.Month(02), 28, 23, 59, 59, 0, time.UTC), []string{"trello_card_id"})
+	if err != nil {
+		t.Fatalf("Error in TogglTime Store: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected one inserted time entry")
+}
+
+func TestTogglNewClientThrowsUnsupportedApiVersionError(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+
+	_, err = NewClient(configuration.Configuration{TogglConfiguration: configuration.TogglConfiguration{ApiVersion: "v7"}}, logger, httpclient.NewClient(logger, &http.Client{}, 0, 0))
+	switch err.(type) {
+	case *UnsupportedApiVersionError:
+		return
+	default:
+		t.Errorf("Expect an UnsupportedApiVersionError in NewClient with an unknown API version")
+	}
+}
@@ -0,0 +1,173 @@
+package trello
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	trelloLib "github.com/adlio/trello"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+)
+
+// trelloCardDimensionColumns defines the columns of the "trello_card_dimension" database table, in the order of the TrelloCardDimension fields.
+var trelloCardDimensionColumns = []string{"card_id", "name", "value", "weight"}
+
+// TrelloCardDimension struct defines the value of a dimension of a Trello card, e.g. the "epic" or the "priority".
+// The Weight is the fraction of the card working hours assigned to the value, and the weights of the values of a dimension sum to 1.
+type TrelloCardDimension struct {
+	Name   string
+	Value  string
+	Weight float64
+}
+
+// MultipleDimensionValuesError defines the error for a card with multiple labels that belong to a dimension with the "error" policy.
+type MultipleDimensionValuesError struct {
+	CardId    string
+	CardName  string
+	Dimension string
+	Values    []string
+}
+
+func (err *MultipleDimensionValuesError) Error() string {
+	return fmt.Sprintf("The Trello card %s %q has multiple values for the dimension %s: %s.", err.CardId, err.CardName, err.Dimension, strings.Join(err.Values, ", "))
+}
+
+// dimensionMatcher struct defines the matching of the labels of a dimension.
+type dimensionMatcher struct {
+	configuration configuration.TrelloDimensionConfiguration
+	labelRegexp   *regexp.Regexp
+}
+
+// newDimensionMatchers creates the dimensionMatchers of the dimensions.
+func newDimensionMatchers(dimensions []configuration.TrelloDimensionConfiguration) ([]dimensionMatcher, error) {
+	var dimensionMatchers = make([]dimensionMatcher, len(dimensions))
+	for i, dimension := range dimensions {
+		dimensionMatchers[i] = dimensionMatcher{configuration: dimension}
+		if dimension.LabelRegex != "" {
+			labelRegexp, err := regexp.Compile(dimension.LabelRegex)
+			if err != nil {
+				return nil, err
+			}
+			dimensionMatchers[i].labelRegexp = labelRegexp
+		}
+	}
+	return dimensionMatchers, nil
+}
+
+// match returns the dimension value of the label, when the label belongs to the dimension.
+// The value is the label name without the prefix, the first group of the regular expression if any, or the label name.
+func (matcher dimensionMatcher) match(label *trelloLib.Label) (string, bool) {
+	if matcher.configuration.LabelPrefix != "" && strings.HasPrefix(label.Name, matcher.configuration.LabelPrefix) {
+		return strings.TrimSpace(strings.TrimPrefix(label.Name, matcher.configuration.LabelPrefix)), true
+	}
+	if matcher.labelRegexp != nil {
+		if groups := matcher.labelRegexp.FindStringSubmatch(label.Name); groups != nil {
+			if len(groups) > 1 {
+				return groups[1], true
+			}
+			return label.Name, true
+		}
+	}
+	if contains(matcher.configuration.LabelColors, label.Color) {
+		return label.Name, true
+	}
+	return "", false
+}
+
+// cardDimensions returns the dimension values of the card custom fields and labels, according to the policy of each dimension.
+// The value of the custom field of a dimension takes precedence over the labels.
+func cardDimensions(dimensionMatchers []dimensionMatcher, card *trelloLib.Card, customFields map[string]interface{}) ([]TrelloCardDimension, error) {
+	var dimensions []TrelloCardDimension
+	for _, matcher := range dimensionMatchers {
+		if matcher.configuration.CustomField != "" {
+			if value := customFieldString(customFields[matcher.configuration.CustomField]); value != "" {
+				dimensions = append(dimensions, TrelloCardDimension{Name: matcher.configuration.Name, Value: value, Weight: 1})
+				continue
+			}
+		}
+		var values []string
+		for _, label := range card.Labels {
+			if value, matched := matcher.match(label); matched && !contains(values, value) {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		switch matcher.configuration.Policy {
+		case configuration.DimensionPolicyFirst:
+			values = values[:1]
+		case configuration.DimensionPolicyLast:
+			values = values[len(values)-1:]
+		case configuration.DimensionPolicyError:
+			if len(values) > 1 {
+				return nil, &MultipleDimensionValuesError{CardId: card.ID, CardName: card.Name, Dimension: matcher.configuration.Name, Values: values}
+			}
+		}
+		dimensions = append(dimensions, matcher.weightedDimensions(values)...)
+	}
+	return dimensions, nil
+}
+
+// weightedDimensions splits the card between the dimension values by the configured weights, or evenly when the values have no weight.
+func (matcher dimensionMatcher) weightedDimensions(values []string) []TrelloCardDimension {
+	var weights = make([]float64, len(values))
+	total := 0.0
+	for i, value := range values {
+		weights[i] = 1
+		for _, weight := range matcher.configuration.Weights {
+			if weight.Value == value {
+				weights[i] = weight.Weight
+			}
+		}
+		total += weights[i]
+	}
+	var dimensions = make([]TrelloCardDimension, len(values))
+	for i, value := range values {
+		dimensions[i] = TrelloCardDimension{Name: matcher.configuration.Name, Value: value, Weight: weights[i] / total}
+	}
+	return dimensions
+}
+
+// dimensionValue returns the first value of the named dimension, or an empty string when the card has no value for the dimension.
+func dimensionValue(dimensions []TrelloCardDimension, name string) string {
+	for _, dimension := range dimensions {
+		if dimension.Name == name {
+			return dimension.Value
+		}
+	}
+	return ""
+}
+
+// customFieldString returns the text of a custom field value, or an empty string when the value is missing.
+func customFieldString(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case time.Time:
+		return value.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// customFieldNumber returns the number of a custom field value, and false when the value is missing or not a number.
+func customFieldNumber(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
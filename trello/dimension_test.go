@@ -0,0 +1,95 @@
+package trello
+
+import (
+	"testing"
+
+	trelloLib "github.com/adlio/trello"
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+)
+
+func newTestCard(labelNames ...string) *trelloLib.Card {
+	card := &trelloLib.Card{ID: "45636633", Name: "Card name"}
+	for _, labelName := range labelNames {
+		card.Labels = append(card.Labels, &trelloLib.Label{Name: labelName, Color: "green"})
+	}
+	return card
+}
+
+func TestCardDimensionsWithPolicy(t *testing.T) {
+	for policy, expectedValues := range map[string][]string{
+		configuration.DimensionPolicyAll:   {"Acme", "Globex"},
+		configuration.DimensionPolicyFirst: {"Acme"},
+		configuration.DimensionPolicyLast:  {"Globex"},
+	} {
+		dimensionMatchers, err := newDimensionMatchers([]configuration.TrelloDimensionConfiguration{{Name: "customer", LabelColors: []string{"green"}, Policy: policy}})
+		if err != nil {
+			t.Fatalf("Error creating the dimension matchers: %v", err)
+		}
+
+		dimensions, err := cardDimensions(dimensionMatchers, newTestCard("Acme", "Globex"), nil)
+		if err != nil {
+			t.Fatalf("Error in cardDimensions with the policy %s: %v", policy, err)
+		}
+		var values []string
+		for _, dimension := range dimensions {
+			values = append(values, dimension.Value)
+		}
+		assert.Equal(t, expectedValues, values, "Expected the dimension values of the policy "+policy)
+	}
+}
+
+func TestCardDimensionsSplitsWeights(t *testing.T) {
+	dimensionMatchers, err := newDimensionMatchers([]configuration.TrelloDimensionConfiguration{
+		{Name: "customer", LabelPrefix: "cust:", Policy: configuration.DimensionPolicyAll, Weights: []configuration.TrelloDimensionWeightConfiguration{{Value: "Acme", Weight: 3}}},
+		{Name: "team", LabelPrefix: "team:", Policy: configuration.DimensionPolicyAll},
+	})
+	if err != nil {
+		t.Fatalf("Error creating the dimension matchers: %v", err)
+	}
+
+	dimensions, err := cardDimensions(dimensionMatchers, newTestCard("cust:Acme", "cust:Globex", "team:Red", "team:Blue"), nil)
+	if err != nil {
+		t.Fatalf("Error in cardDimensions: %v", err)
+	}
+	assert.Equal(t, []TrelloCardDimension{
+		{Name: "customer", Value: "Acme", Weight: 0.75},
+		{Name: "customer", Value: "Globex", Weight: 0.25},
+		{Name: "team", Value: "Red", Weight: 0.5},
+		{Name: "team", Value: "Blue", Weight: 0.5},
+	}, dimensions, "Expected the dimension values split by weight, or evenly")
+}
+
+func TestCardDimensionsThrowsMultipleDimensionValuesError(t *testing.T) {
+	dimensionMatchers, err := newDimensionMatchers([]configuration.TrelloDimensionConfiguration{{Name: "customer", LabelColors: []string{"green"}, Policy: configuration.DimensionPolicyError}})
+	if err != nil {
+		t.Fatalf("Error creating the dimension matchers: %v", err)
+	}
+
+	_, err = cardDimensions(dimensionMatchers, newTestCard("Acme", "Globex"), nil)
+	switch err := err.(type) {
+	case *MultipleDimensionValuesError:
+		assert.Equal(t, []string{"Acme", "Globex"}, err.Values, "Expected the dimension values")
+	default:
+		t.Errorf("Expect a MultipleDimensionValuesError in cardDimensions with the error policy, got %v", err)
+	}
+}
+
+func TestCardDimensionsFromCustomFieldWithLabelsFallback(t *testing.T) {
+	dimensionMatchers, err := newDimensionMatchers([]configuration.TrelloDimensionConfiguration{{Name: "customer", LabelColors: []string{"green"}, CustomField: "Customer"}})
+	if err != nil {
+		t.Fatalf("Error creating the dimension matchers: %v", err)
+	}
+
+	dimensions, err := cardDimensions(dimensionMatchers, newTestCard("Acme"), map[string]interface{}{"Customer": "Globex"})
+	if err != nil {
+		t.Fatalf("Error in cardDimensions: %v", err)
+	}
+	assert.Equal(t, []TrelloCardDimension{{Name: "customer", Value: "Globex", Weight: 1}}, dimensions, "Expected the dimension value from the custom field")
+
+	dimensions, err = cardDimensions(dimensionMatchers, newTestCard("Acme"), map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Error in cardDimensions: %v", err)
+	}
+	assert.Equal(t, []TrelloCardDimension{{Name: "customer", Value: "Acme", Weight: 1}}, dimensions, "Expected the dimension value from the labels")
+}
@@ -0,0 +1,60 @@
+package trello
+
+import (
+	"regexp"
+
+	trelloLib "github.com/adlio/trello"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+)
+
+// estimateMatcher struct defines the sources of the Trello card estimate of a board.
+type estimateMatcher struct {
+	customField string
+	nameRegex   *regexp.Regexp
+	labelRegex  *regexp.Regexp
+}
+
+func newEstimateMatcher(boardConfiguration configuration.TrelloBoardConfiguration) (matcher estimateMatcher, err error) {
+	matcher.customField = boardConfiguration.EstimateCustomField
+	if boardConfiguration.EstimateNameRegex != "" {
+		matcher.nameRegex, err = regexp.Compile(boardConfiguration.EstimateNameRegex)
+		if err != nil {
+			return
+		}
+	}
+	if boardConfiguration.EstimateLabelRegex != "" {
+		matcher.labelRegex, err = regexp.Compile(boardConfiguration.EstimateLabelRegex)
+	}
+	return
+}
+
+// estimate returns the estimate of the Trello card, or zero when the card is not estimated.
+// The custom field takes precedence over the estimate in the card name, which takes precedence over the estimate in the labels.
+func (matcher estimateMatcher) estimate(card *trelloLib.Card, customFields map[string]interface{}) float64 {
+	if matcher.customField != "" {
+		if estimate, found := customFieldNumber(customFields[matcher.customField]); found {
+			return estimate
+		}
+	}
+	if estimate, found := regexNumber(matcher.nameRegex, card.Name); found {
+		return estimate
+	}
+	for _, label := range card.Labels {
+		if estimate, found := regexNumber(matcher.labelRegex, label.Name); found {
+			return estimate
+		}
+	}
+	return 0
+}
+
+// regexNumber returns the number captured by the first group of the regular expression.
+func regexNumber(regex *regexp.Regexp, value string) (float64, bool) {
+	if regex == nil {
+		return 0, false
+	}
+	matches := regex.FindStringSubmatch(value)
+	if len(matches) < 2 {
+		return 0, false
+	}
+	return customFieldNumber(matches[1])
+}
@@ -0,0 +1,26 @@
+package trello
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+)
+
+func TestCardEstimate(t *testing.T) {
+	estimateMatcher, err := newEstimateMatcher(configuration.TrelloBoardConfiguration{
+		EstimateCustomField: "Story points",
+		EstimateNameRegex:   `^\s*\((\d+(?:\.\d+)?)\)`,
+		EstimateLabelRegex:  `^(\d+) SP$`,
+	})
+	if err != nil {
+		t.Fatalf("Error creating the estimate matcher: %v", err)
+	}
+
+	card := newTestCard("Acme", "3 SP")
+	assert.Equal(t, 3.0, estimateMatcher.estimate(card, nil), "Expected the estimate from the label")
+	card.Name = "(5) Card name"
+	assert.Equal(t, 5.0, estimateMatcher.estimate(card, nil), "Expected the estimate from the card name")
+	assert.Equal(t, 8.0, estimateMatcher.estimate(card, map[string]interface{}{"Story points": "8"}), "Expected the estimate from the custom field")
+	assert.Equal(t, 0.0, estimateMatcher.estimate(newTestCard("Acme"), nil), "Expected no estimate")
+}
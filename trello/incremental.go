@@ -0,0 +1,99 @@
+package trello
+
+import (
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// The sources of the Trello boards in the "sync_state" database table, for the cards and for the card transitions.
+const (
+	SourceTrelloBoard            = "trello_board"
+	SourceTrelloBoardTransitions = "trello_board_transitions"
+)
+
+// SinceClient interface defines the Trello client primitives of the incremental sync.
+type SinceClient interface {
+	GetBoardIds() []string
+	GetModifiedBoardIds(since map[string]time.Time) ([]string, error)
+	Since(boardIds []string, since map[string]time.Time) Client
+}
+
+// StoreIncremental inserts or updates the labels and the cards of the boards with actions since the last sync, and records the sync time of all the boards.
+// The Trello API can't filter the cards by modification time, so all the cards of a modified board are retrieved. The boards never synced before are always retrieved.
+func (trello *Trello) StoreIncremental(sinceClient SinceClient, preservedColumns []string) (labelsReport storage.SyncReport, report storage.SyncReport, modifiedBoards int, err error) {
+	if trello.databaseConnection == nil {
+		return labelsReport, report, modifiedBoards, &application_errors.DatabaseConnectionError{}
+	}
+	syncedAt := time.Now().UTC().Truncate(time.Second)
+	since, err := trello.since(SourceTrelloBoard)
+	if err != nil {
+		return
+	}
+	modifiedBoardIds, err := sinceClient.GetModifiedBoardIds(since)
+	if err != nil {
+		return
+	}
+	modifiedBoards = len(modifiedBoardIds)
+	if modifiedBoards > 0 {
+		modifiedBoardsTrello := &Trello{
+			logger:             trello.logger,
+			trelloClient:       sinceClient.Since(modifiedBoardIds, since),
+			databaseConnection: trello.databaseConnection,
+		}
+		labelsReport, err = modifiedBoardsTrello.StoreLabels()
+		if err != nil {
+			return
+		}
+		report, err = modifiedBoardsTrello.Store(preservedColumns)
+		if err != nil {
+			return
+		}
+	}
+	err = storage.SaveSyncState(trello.databaseConnection, SourceTrelloBoard, sinceClient.GetBoardIds(), syncedAt)
+	return
+}
+
+// StoreTransitionsIncremental inserts or updates the Trello card transition entries of the actions since the last sync of each board,
+// and records the sync time of all the boards. All the actions of the boards never synced before are retrieved.
+func (trello *Trello) StoreTransitionsIncremental(sinceClient SinceClient) (report storage.SyncReport, err error) {
+	if trello.databaseConnection == nil {
+		return report, &application_errors.DatabaseConnectionError{}
+	}
+	upsert, err := storage.NewUpsert("trello_card_transition", trelloCardTransitionColumns, nil)
+	if err != nil {
+		return
+	}
+	syncedAt := time.Now().UTC().Truncate(time.Second)
+	since, err := trello.since(SourceTrelloBoardTransitions)
+	if err != nil {
+		return
+	}
+	boardIds := sinceClient.GetBoardIds()
+	trelloCardTransitionEntries, err := sinceClient.Since(boardIds, since).GetCardTransitions()
+	if err != nil {
+		return
+	}
+	err = trello.storeTransitionsInDatabase(upsert, &report, trelloCardTransitionEntries)
+	if err != nil {
+		return
+	}
+	trello.logger.Info("Stored Trello card transition entries", zap.Int("inserted", report.Inserted), zap.Int("updated", report.Updated), zap.Int("unchanged", report.Unchanged))
+	err = storage.SaveSyncState(trello.databaseConnection, SourceTrelloBoardTransitions, boardIds, syncedAt)
+	return
+}
+
+// since retrieves the last sync time of the boards, moved back by the sync overlap.
+func (trello *Trello) since(source string) (map[string]time.Time, error) {
+	syncState, err := storage.GetSyncState(trello.databaseConnection, source)
+	if err != nil {
+		return nil, err
+	}
+	since := make(map[string]time.Time, len(syncState))
+	for boardId, lastSync := range syncState {
+		since[boardId] = lastSync.Add(-storage.SyncOverlap)
+	}
+	return since, nil
+}
@@ -0,0 +1,169 @@
+package trello
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	trelloLib "github.com/adlio/trello"
+	"github.com/bmizerany/assert"
+	"github.com/lib/pq"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+)
+
+type MockSinceClient struct {
+	modifiedBoardIds []string
+	since            map[string]time.Time
+}
+
+func (mockSinceClient *MockSinceClient) GetBoardIds() []string {
+	return []string{"5f1a2b3c", "5f1a2b3f"}
+}
+
+func (mockSinceClient *MockSinceClient) GetModifiedBoardIds(since map[string]time.Time) ([]string, error) {
+	mockSinceClient.since = since
+	return mockSinceClient.modifiedBoardIds, nil
+}
+
+func (mockSinceClient *MockSinceClient) Since(boardIds []string, since map[string]time.Time) Client {
+	mockSinceClient.since = since
+	return &MockTrelloClient{}
+}
+
+func TestTrelloClientGetModifiedBoardIds(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2021-02-01T09:00:00Z", r.URL.Query().Get("since"), "Expected the actions since the last sync")
+		switch r.URL.Path {
+		case "/1/boards/board1/actions":
+			fmt.Fprint(w, `[]`)
+		case "/1/boards/board2/actions":
+			fmt.Fprint(w, `[{"id": "action1"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := trelloLib.NewClient("key", "token")
+	client.BaseURL = server.URL + "/1"
+	config := configuration.Configuration{TrelloConfiguration: configuration.TrelloConfiguration{Boards: []configuration.TrelloBoardConfiguration{{Id: "board1"}, {Id: "board2"}, {Id: "board3"}}}}
+	trelloClient := NewTrelloClient(config, logger, client)
+	lastSync := time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC)
+
+	modifiedBoardIds, err := trelloClient.GetModifiedBoardIds(map[string]time.Time{"board1": lastSync, "board2": lastSync})
+	if err != nil {
+		t.Fatalf("Error in TrelloClient GetModifiedBoardIds: %v", err)
+	}
+	assert.Equal(t, []string{"board2", "board3"}, modifiedBoardIds, "Expected the modified board and the board never synced")
+}
+
+func TestTrelloClientSinceGetCardTransitions(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1/boards/board1":
+			fmt.Fprint(w, `{"id": "board1", "name": "Customer board"}`)
+		case "/1/boards/board1/lists":
+			fmt.Fprint(w, `[{"id": "list1", "name": "Backlog"}]`)
+		case "/1/boards/board1/actions":
+			assert.Equal(t, "2021-02-01T09:00:00Z", r.URL.Query().Get("since"), "Expected the actions since the last sync")
+			fmt.Fprint(w, `[{"id": "action1", "type": "createCard", "date": "2021-02-01T10:00:00.000Z", "data": {"card": {"id": "card1"}, "list": {"id": "list1", "name": "Backlog"}}}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := trelloLib.NewClient("key", "token")
+	client.BaseURL = server.URL + "/1"
+	config := configuration.Configuration{TrelloConfiguration: configuration.TrelloConfiguration{Boards: []configuration.TrelloBoardConfiguration{{Id: "board1"}, {Id: "board2"}}}}
+	trelloClient := NewTrelloClient(config, logger, client)
+
+	trelloCardTransitionEntries, err := trelloClient.Since([]string{"board1"}, map[string]time.Time{"board1": time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC)}).GetCardTransitions()
+	if err != nil {
+		t.Fatalf("Error in TrelloClient GetCardTransitions: %v", err)
+	}
+	assert.Equal(t, 1, len(trelloCardTransitionEntries), "Expected the card transition of the selected board")
+}
+
+func TestTrelloStoreIncrementalSkipsUnmodifiedBoards(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	trello, err := NewTrelloWithDatabaseConnection(logger, &MockTrelloClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating Trello: %v", err)
+	}
+	sinceClient := &MockSinceClient{}
+	lastSync := time.Date(2021, time.Month(02), 01, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT source_id, synced_at FROM sync_state").
+		WithArgs(SourceTrelloBoard).
+		WillReturnRows(sqlmock.NewRows([]string{"source_id", "synced_at"}).AddRow("5f1a2b3c", lastSync).AddRow("5f1a2b3f", lastSync))
+	mock.ExpectExec("INSERT INTO sync_state").
+		WithArgs(SourceTrelloBoard, pq.Array([]string{"5f1a2b3c", "5f1a2b3f"}), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	_, _, modifiedBoards, err := trello.StoreIncremental(sinceClient, nil)
+	if err != nil {
+		t.Fatalf("Error in Trello StoreIncremental: %v", err)
+	}
+	assert.Equal(t, 0, modifiedBoards, "Expected no modified board")
+	assert.Equal(t, map[string]time.Time{"5f1a2b3c": lastSync.Add(-storage.SyncOverlap), "5f1a2b3f": lastSync.Add(-storage.SyncOverlap)}, sinceClient.since, "Expected the boards modified since the last sync")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Expect only the sync time of the boards to be saved: %v", err)
+	}
+}
+
+func TestTrelloStoreTransitionsIncremental(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	trello, err := NewTrelloWithDatabaseConnection(logger, &MockTrelloClient{}, db)
+	if err != nil {
+		t.Fatalf("Error creating Trello: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT source_id, synced_at FROM sync_state").
+		WithArgs(SourceTrelloBoardTransitions).
+		WillReturnRows(sqlmock.NewRows([]string{"source_id", "synced_at"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO trello_card_transition").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectCommit()
+	mock.ExpectExec("INSERT INTO sync_state").
+		WithArgs(SourceTrelloBoardTransitions, pq.Array([]string{"5f1a2b3c", "5f1a2b3f"}), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	report, err := trello.StoreTransitionsIncremental(&MockSinceClient{})
+	if err != nil {
+		t.Fatalf("Error in Trello StoreTransitionsIncremental: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected one inserted card transition entry")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled database expectations: %v", err)
+	}
+}
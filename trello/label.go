@@ -0,0 +1,65 @@
+package trello
+
+import (
+	"database/sql"
+
+	trelloLib "github.com/adlio/trello"
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// trelloLabelColumns defines the columns of the "trello_label" database table, in the order of the TrelloLabelEntry fields.
+var trelloLabelColumns = []string{"id", "board_id", "name", "color"}
+
+// TrelloLabelEntry struct defines the Trello label of a board, which is referenced by ID from the Trello cards.
+type TrelloLabelEntry struct {
+	Id       string
+	Board_id string
+	Name     string
+	Color    string
+}
+
+// StoreLabels inserts or updates the Trello labels of the boards into the database, so that the renames reach all the stored cards.
+// The labels deleted in Trello are kept, since the stored cards may reference them.
+func (trello *Trello) StoreLabels() (report storage.SyncReport, err error) {
+	if trello.databaseConnection == nil {
+		return report, &application_errors.DatabaseConnectionError{}
+	}
+	upsert, err := storage.NewUpsert("trello_label", trelloLabelColumns, nil)
+	if err != nil {
+		return
+	}
+	trelloLabelEntries, err := trello.trelloClient.GetLabels()
+	if err != nil {
+		return
+	}
+	rows := make([][]interface{}, len(trelloLabelEntries))
+	for i, entry := range trelloLabelEntries {
+		rows[i] = []interface{}{entry.Id, entry.Board_id, entry.Name, entry.Color}
+	}
+	var batchReport storage.SyncReport
+	err = storage.WithTransaction(trello.databaseConnection, func(tx *sql.Tx) error {
+		return upsert.Execute(tx, &batchReport, rows)
+	})
+	if err != nil {
+		return
+	}
+	report = batchReport
+	trello.logger.Info("Stored Trello label entries", zap.Int("inserted", report.Inserted), zap.Int("updated", report.Updated), zap.Int("unchanged", report.Unchanged))
+	return
+}
+
+// newTrelloLabelEntries creates the Trello label entries of a board.
+func newTrelloLabelEntries(boardId string, labels []*trelloLib.Label) []TrelloLabelEntry {
+	trelloLabelEntries := make([]TrelloLabelEntry, len(labels))
+	for i, label := range labels {
+		trelloLabelEntries[i] = TrelloLabelEntry{
+			Id:       label.ID,
+			Board_id: boardId,
+			Name:     label.Name,
+			Color:    label.Color,
+		}
+	}
+	return trelloLabelEntries
+}
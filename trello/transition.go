@@ -0,0 +1,86 @@
+package trello
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
+	"go.uber.org/zap"
+)
+
+// The Trello action types stored as card transitions.
+const (
+	ActionCreateCard = "createCard"
+	ActionUpdateCard = "updateCard"
+)
+
+// trelloCardTransitionColumns defines the columns of the "trello_card_transition" database table, in the order of the TrelloCardTransitionEntry fields.
+var trelloCardTransitionColumns = []string{"id", "card_id", "board_id", "action_type", "from_list_id", "from_list_name", "to_list_id", "to_list_name", "to_status", "date"}
+
+// TrelloCardTransitionEntry struct defines the move of a Trello card into a list, either on creation or from another list.
+type TrelloCardTransitionEntry struct {
+	Id             string
+	Card_id        string
+	Board_id       string
+	Action_type    string
+	From_list_id   string
+	From_list_name string
+	To_list_id     string
+	To_list_name   string
+	To_status      string
+	Date           time.Time
+}
+
+// EmptyTrelloCardTransitionsError defines the empty Trello card transitions error.
+type EmptyTrelloCardTransitionsError struct {
+}
+
+func (error *EmptyTrelloCardTransitionsError) Error() string {
+	return fmt.Sprintf("The Trello card transition entries are empty.")
+}
+
+// StoreTransitions inserts or updates the Trello card transition entries into the database.
+func (trello *Trello) StoreTransitions() (report storage.SyncReport, err error) {
+	upsert, err := storage.NewUpsert("trello_card_transition", trelloCardTransitionColumns, nil)
+	if err != nil {
+		return
+	}
+	trelloCardTransitionEntries, err := trello.trelloClient.GetCardTransitions()
+	if err != nil {
+		return
+	}
+	if len(trelloCardTransitionEntries) == 0 {
+		trello.logger.Error("Skip the storage of the Trello card transition entries.")
+		return report, &EmptyTrelloCardTransitionsError{}
+	}
+	err = trello.storeTransitionsInDatabase(upsert, &report, trelloCardTransitionEntries)
+	if err != nil {
+		return
+	}
+	trello.logger.Info("Stored Trello card transition entries", zap.Int("inserted", report.Inserted), zap.Int("updated", report.Updated), zap.Int("unchanged", report.Unchanged))
+	return
+}
+
+// storeTransitionsInDatabase writes all the Trello card transition entries in a single transaction.
+func (trello *Trello) storeTransitionsInDatabase(upsert *storage.Upsert, report *storage.SyncReport, trelloCardTransitionEntries []TrelloCardTransitionEntry) error {
+	if trello.databaseConnection == nil {
+		return &application_errors.DatabaseConnectionError{}
+	}
+	rows := make([][]interface{}, len(trelloCardTransitionEntries))
+	for i, entry := range trelloCardTransitionEntries {
+		rows[i] = []interface{}{
+			entry.Id, entry.Card_id, entry.Board_id, entry.Action_type, entry.From_list_id, entry.From_list_name,
+			entry.To_list_id, entry.To_list_name, entry.To_status, entry.Date}
+	}
+	var batchReport storage.SyncReport
+	err := storage.WithTransaction(trello.databaseConnection, func(tx *sql.Tx) error {
+		return upsert.Execute(tx, &batchReport, rows)
+	})
+	if err != nil {
+		return err
+	}
+	*report = batchReport
+	return nil
+}
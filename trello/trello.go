@@ -13,6 +13,8 @@ import (
 // TrelloClient interface defines the Trello client primitives.
 type Client interface {
 	GetCards() ([]TrelloCardEntry, error)
+	GetCardTransitions() ([]TrelloCardTransitionEntry, error)
+	GetLabels() ([]TrelloLabelEntry, error)
 }
 
 // Trello struct defines the Trello service.
@@ -22,18 +24,37 @@ type Trello struct {
 	databaseConnection *sql.DB
 }
 
+// trelloCardColumns defines the columns of the "trello_card" database table, in the order of the TrelloCardEntry fields.
+var trelloCardColumns = []string{"id", "name", "closed", "labels", "label_ids", "project", "customer", "team", "type", "short_link", "board_id", "board_name", "list_id", "list_name", "status", "estimate"}
+
 // TrelloCardEntry struct defines the Trello card entry.
 type TrelloCardEntry struct {
-	Id       string
-	Name     string
-	Closed   bool
-	Labels   []string
-	Project  string
-	Customer string
-	Team     string
-	Type     string
+	Id         string
+	Name       string
+	Closed     bool
+	Labels     []string
+	Label_ids  []string
+	Project    string
+	Customer   string
+	Team       string
+	Type       string
+	Short_link string
+	Board_id   string
+	Board_name string
+	List_id    string
+	List_name  string
+	Status     string
+	Estimate   float64
+	Dimensions []TrelloCardDimension `csv:"-"`
 }
 
+// The workflow states of the Trello cards, resolved from the Trello list of the card.
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in-progress"
+	StatusDone       = "done"
+)
+
 // EmptyTrelloCardsError defines the empty Trello cards error.
 type EmptyTrelloCardsError struct {
 }
@@ -97,58 +118,63 @@ func (trello *Trello) DownloadAsCsv() (err error) {
 	return downloadStructAsCsv.DownloadAll(values, "trello_entries")
 }
 
-// Store inserts the Trello card entries into the database.
-func (trello *Trello) Store() (err error) {
+// Store inserts or updates the Trello card entries into the database.
+// The preservedColumns keep the value already stored in the database when a card entry is updated.
+func (trello *Trello) Store(preservedColumns []string) (report storage.SyncReport, err error) {
+	upsert, err := storage.NewUpsert("trello_card", trelloCardColumns, preservedColumns)
+	if err != nil {
+		return
+	}
 	trelloCardEntries, err := trello.trelloClient.GetCards()
 	if err != nil {
 		return
 	}
 	if len(trelloCardEntries) == 0 {
 		trello.logger.Error("Skip the creation of the Trello card entries file.")
-		return &EmptyTrelloCardsError{}
+		return report, &EmptyTrelloCardsError{}
 	}
-	for _, trelloCardEntry := range trelloCardEntries {
-		//fmt.Printf("%+v", trelloCardEntry)
-		err = trello.storeInDatabase(trelloCardEntry)
-		if err != nil {
-			return
-		}
+	err = trello.storeInDatabase(upsert, &report, trelloCardEntries)
+	if err != nil {
+		return
 	}
+	trello.logger.Info("Stored Trello card entries", zap.Int("inserted", report.Inserted), zap.Int("updated", report.Updated), zap.Int("unchanged", report.Unchanged))
 	return
 }
 
-func (trello *Trello) storeInDatabase(trelloCardEntry TrelloCardEntry) (err error) {
+// storeInDatabase writes all the Trello card entries in a single transaction, so that a failed sync leaves the database unchanged.
+// The dimensions of the stored cards are replaced by the current dimensions.
+func (trello *Trello) storeInDatabase(upsert *storage.Upsert, report *storage.SyncReport, trelloCardEntries []TrelloCardEntry) error {
 	if trello.databaseConnection == nil {
-		err = &application_errors.DatabaseConnectionError{}
-		return
-	}
-	tx, err := trello.databaseConnection.Begin()
-	if err != nil {
-		return
+		return &application_errors.DatabaseConnectionError{}
+	}
+	rows := make([][]interface{}, len(trelloCardEntries))
+	for i, trelloCardEntry := range trelloCardEntries {
+		rows[i] = []interface{}{
+			trelloCardEntry.Id, trelloCardEntry.Name, trelloCardEntry.Closed, pq.Array(trelloCardEntry.Labels), pq.Array(trelloCardEntry.Label_ids),
+			trelloCardEntry.Project, trelloCardEntry.Customer, trelloCardEntry.Team, trelloCardEntry.Type, trelloCardEntry.Short_link,
+			trelloCardEntry.Board_id, trelloCardEntry.Board_name, trelloCardEntry.List_id, trelloCardEntry.List_name, trelloCardEntry.Status, trelloCardEntry.Estimate}
+	}
+	cardIds := make([]string, len(trelloCardEntries))
+	var dimensionRows [][]interface{}
+	for i, trelloCardEntry := range trelloCardEntries {
+		cardIds[i] = trelloCardEntry.Id
+		for _, dimension := range trelloCardEntry.Dimensions {
+			dimensionRows = append(dimensionRows, []interface{}{trelloCardEntry.Id, dimension.Name, dimension.Value, dimension.Weight})
+		}
 	}
-	defer func() {
-		switch err {
-		case nil:
-			sqlerr := tx.Commit()
-			if err == nil {
-				err = sqlerr
-			}
-		default:
-			sqlerr := tx.Rollback()
-			if err == nil {
-				err = sqlerr
-			}
+	var batchReport storage.SyncReport
+	err := storage.WithTransaction(trello.databaseConnection, func(tx *sql.Tx) error {
+		err := upsert.Execute(tx, &batchReport, rows)
+		if err != nil {
+			return err
 		}
-	}()
-	sqlStmt := `INSERT INTO trello_card(id, name, closed, labels, project, customer, team, type) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err = trello.databaseConnection.Exec(
-		sqlStmt,
-		trelloCardEntry.Id, trelloCardEntry.Name, trelloCardEntry.Closed, pq.Array(trelloCardEntry.Labels),
-		trelloCardEntry.Project, trelloCardEntry.Customer, trelloCardEntry.Team, trelloCardEntry.Type)
+		return storage.NewReplace("trello_card_dimension", trelloCardDimensionColumns).Execute(tx, cardIds, dimensionRows)
+	})
 	if err != nil {
-		return
+		return err
 	}
-	return
+	*report = batchReport
+	return nil
 }
 
 func contains(labels []string, value string) bool {
@@ -1,16 +1,25 @@
 package trello
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	trelloLib "github.com/adlio/trello"
 	"github.com/sitMCella/toggl-trello-kpi/configuration"
 	"go.uber.org/zap"
 )
 
+// actionsPageSize defines the number of actions retrieved with a single request, which is the maximum allowed by the Trello API.
+const actionsPageSize = 1000
+
 // TrelloClient implements the Trello Client interface.
 type TrelloClient struct {
 	logger        *zap.Logger
 	client        *trelloLib.Client
 	configuration configuration.TrelloConfiguration
+	// since limits the retrieved actions to the ones after the time, by board ID.
+	since map[string]time.Time
 }
 
 // NewTrelloClient creates a new TrelloClient.
@@ -22,49 +31,286 @@ func NewTrelloClient(config configuration.Configuration, logger *zap.Logger, tre
 	}
 }
 
-// GetCards retrieves all the Trello cards from the board.
+// GetCards retrieves all the Trello cards from the configured boards.
 func (trelloClient *TrelloClient) GetCards() ([]TrelloCardEntry, error) {
-	board, err := trelloClient.client.GetBoard(trelloClient.configuration.BoardId, trelloLib.Defaults())
+	var trelloCardEntries []TrelloCardEntry
+	for _, boardConfiguration := range trelloClient.configuration.Boards {
+		boardCardEntries, err := trelloClient.getBoardCards(boardConfiguration)
+		if err != nil {
+			return nil, err
+		}
+		trelloCardEntries = append(trelloCardEntries, boardCardEntries...)
+	}
+	return trelloCardEntries, nil
+}
+
+// getBoardCards retrieves the Trello cards from a board.
+func (trelloClient *TrelloClient) getBoardCards(boardConfiguration configuration.TrelloBoardConfiguration) ([]TrelloCardEntry, error) {
+	board, err := trelloClient.client.GetBoard(boardConfiguration.Id, trelloLib.Defaults())
+	if err != nil {
+		return nil, err
+	}
+	var boardCustomFields []*trelloLib.CustomField
+	cardArguments := trelloLib.Defaults()
+	if usesCustomFields(boardConfiguration) {
+		boardCustomFields, err = board.GetCustomFields(trelloLib.Defaults())
+		if err != nil {
+			return nil, err
+		}
+		cardArguments["customFieldItems"] = "true"
+	}
+	cards, err := board.GetCards(cardArguments)
 	if err != nil {
 		return nil, err
 	}
-	cards, err := board.GetCards(trelloLib.Defaults())
+	listNames, err := getListNames(board)
+	if err != nil {
+		return nil, err
+	}
+	trelloClient.logger.Info("Trello board cards", zap.String("board", board.Name), zap.Int("count", len(cards)))
+	return newTrelloCardEntries(boardConfiguration, board, cards, listNames, boardCustomFields)
+}
+
+// newTrelloCardEntries creates the Trello card entries of a board, using the dimensions and the estimate sources of the board configuration.
+func newTrelloCardEntries(boardConfiguration configuration.TrelloBoardConfiguration, board *trelloLib.Board, cards []*trelloLib.Card, listNames map[string]string, boardCustomFields []*trelloLib.CustomField) ([]TrelloCardEntry, error) {
+	dimensionMatchers, err := newDimensionMatchers(boardConfiguration.Dimensions)
+	if err != nil {
+		return nil, err
+	}
+	estimateMatcher, err := newEstimateMatcher(boardConfiguration)
 	if err != nil {
 		return nil, err
 	}
 	var trelloCardEntries = make([]TrelloCardEntry, len(cards))
 	for i, card := range cards {
 		var labels = make([]string, len(card.Labels))
-		project := ""
-		customer := ""
-		team := ""
-		cardType := ""
+		var labelIds = make([]string, len(card.Labels))
 		for j, label := range card.Labels {
 			labels[j] = label.Name
-			if contains(trelloClient.configuration.LabelProjectColor, label.Color) {
-				project = label.Name
-			}
-			if contains(trelloClient.configuration.LabelCustomerColor, label.Color) {
-				customer = label.Name
-			}
-			if contains(trelloClient.configuration.LabelTeamColor, label.Color) {
-				team = label.Name
-			}
-			if contains(trelloClient.configuration.LabelCardTypeColor, label.Color) {
-				cardType = label.Name
-			}
+			labelIds[j] = label.ID
+		}
+		customFields := card.CustomFields(boardCustomFields)
+		dimensions, err := cardDimensions(dimensionMatchers, card, customFields)
+		if err != nil {
+			return nil, err
 		}
 		trelloCardEntry := TrelloCardEntry{
-			Id:       card.ID,
-			Name:     card.Name,
-			Closed:   card.Closed,
-			Labels:   labels,
-			Project:  project,
-			Customer: customer,
-			Team:     team,
-			Type:     cardType,
+			Id:         card.ID,
+			Name:       card.Name,
+			Closed:     card.Closed,
+			Labels:     labels,
+			Label_ids:  labelIds,
+			Project:    dimensionValue(dimensions, configuration.DimensionProject),
+			Customer:   dimensionValue(dimensions, configuration.DimensionCustomer),
+			Team:       dimensionValue(dimensions, configuration.DimensionTeam),
+			Type:       dimensionValue(dimensions, configuration.DimensionType),
+			Short_link: card.ShortLink,
+			Board_id:   board.ID,
+			Board_name: board.Name,
+			List_id:    card.IDList,
+			List_name:  listNames[card.IDList],
+			Status:     workflowStatus(boardConfiguration, listNames[card.IDList]),
+			Estimate:   estimateMatcher.estimate(card, customFields),
+			Dimensions: dimensions,
 		}
 		trelloCardEntries[i] = trelloCardEntry
 	}
 	return trelloCardEntries, nil
 }
+
+// GetLabels retrieves the Trello labels of the configured boards.
+func (trelloClient *TrelloClient) GetLabels() ([]TrelloLabelEntry, error) {
+	var trelloLabelEntries []TrelloLabelEntry
+	for _, boardConfiguration := range trelloClient.configuration.Boards {
+		board, err := trelloClient.client.GetBoard(boardConfiguration.Id, trelloLib.Defaults())
+		if err != nil {
+			return nil, err
+		}
+		labels, err := board.GetLabels(trelloLib.Defaults())
+		if err != nil {
+			return nil, err
+		}
+		trelloClient.logger.Info("Trello board labels", zap.String("board", board.Name), zap.Int("count", len(labels)))
+		trelloLabelEntries = append(trelloLabelEntries, newTrelloLabelEntries(board.ID, labels)...)
+	}
+	return trelloLabelEntries, nil
+}
+
+// GetCardTransitions retrieves the creation and the list changes of the Trello cards from the actions of the configured boards.
+func (trelloClient *TrelloClient) GetCardTransitions() ([]TrelloCardTransitionEntry, error) {
+	var trelloCardTransitionEntries []TrelloCardTransitionEntry
+	for _, boardConfiguration := range trelloClient.configuration.Boards {
+		boardTransitionEntries, err := trelloClient.getBoardCardTransitions(boardConfiguration)
+		if err != nil {
+			return nil, err
+		}
+		trelloCardTransitionEntries = append(trelloCardTransitionEntries, boardTransitionEntries...)
+	}
+	return trelloCardTransitionEntries, nil
+}
+
+// getBoardCardTransitions retrieves the "createCard" and "updateCard:idList" actions of a board, one page at a time.
+func (trelloClient *TrelloClient) getBoardCardTransitions(boardConfiguration configuration.TrelloBoardConfiguration) ([]TrelloCardTransitionEntry, error) {
+	board, err := trelloClient.client.GetBoard(boardConfiguration.Id, trelloLib.Defaults())
+	if err != nil {
+		return nil, err
+	}
+	listNames, err := getListNames(board)
+	if err != nil {
+		return nil, err
+	}
+	var trelloCardTransitionEntries []TrelloCardTransitionEntry
+	arguments := trelloLib.Arguments{"filter": "createCard,updateCard:idList", "limit": strconv.Itoa(actionsPageSize)}
+	if since, found := trelloClient.since[boardConfiguration.Id]; found {
+		arguments["since"] = since.UTC().Format(time.RFC3339)
+	}
+	for {
+		actions, err := board.GetActions(arguments)
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range actions {
+			trelloCardTransitionEntry, found := newTrelloCardTransitionEntry(boardConfiguration, board, listNames, action)
+			if found {
+				trelloCardTransitionEntries = append(trelloCardTransitionEntries, trelloCardTransitionEntry)
+			}
+		}
+		if len(actions) < actionsPageSize {
+			break
+		}
+		arguments["before"] = actions[len(actions)-1].ID
+	}
+	trelloClient.logger.Info("Trello board card transitions", zap.String("board", board.Name), zap.Int("count", len(trelloCardTransitionEntries)))
+	return trelloCardTransitionEntries, nil
+}
+
+// GetBoardIds returns the IDs of the configured boards.
+func (trelloClient *TrelloClient) GetBoardIds() []string {
+	boardIds := make([]string, len(trelloClient.configuration.Boards))
+	for i, boardConfiguration := range trelloClient.configuration.Boards {
+		boardIds[i] = boardConfiguration.Id
+	}
+	return boardIds
+}
+
+// GetModifiedBoardIds returns the IDs of the configured boards with at least one action after the since time of the board.
+// The boards without a since time are always returned.
+func (trelloClient *TrelloClient) GetModifiedBoardIds(since map[string]time.Time) ([]string, error) {
+	var modifiedBoardIds []string
+	for _, boardConfiguration := range trelloClient.configuration.Boards {
+		boardSince, found := since[boardConfiguration.Id]
+		if found {
+			var actions []*trelloLib.Action
+			err := trelloClient.client.Get("boards/"+boardConfiguration.Id+"/actions", trelloLib.Arguments{"since": boardSince.UTC().Format(time.RFC3339), "limit": "1", "fields": "id"}, &actions)
+			if err != nil {
+				return nil, err
+			}
+			if len(actions) == 0 {
+				continue
+			}
+		}
+		modifiedBoardIds = append(modifiedBoardIds, boardConfiguration.Id)
+	}
+	trelloClient.logger.Info("Trello modified boards", zap.Int("modified", len(modifiedBoardIds)), zap.Int("boards", len(trelloClient.configuration.Boards)))
+	return modifiedBoardIds, nil
+}
+
+// Since returns a TrelloClient that retrieves only the boards of the boardIds, and only the actions after the since time of each board.
+func (trelloClient *TrelloClient) Since(boardIds []string, since map[string]time.Time) Client {
+	sinceClient := *trelloClient
+	sinceClient.configuration.Boards = nil
+	for _, boardConfiguration := range trelloClient.configuration.Boards {
+		if contains(boardIds, boardConfiguration.Id) {
+			sinceClient.configuration.Boards = append(sinceClient.configuration.Boards, boardConfiguration)
+		}
+	}
+	sinceClient.since = since
+	return &sinceClient
+}
+
+// newTrelloCardTransitionEntry creates the Trello card transition entry of a "createCard" or "updateCard:idList" action.
+// The workflow status is resolved from the current name of the list, or from the list name of the action when the list does not exist anymore.
+func newTrelloCardTransitionEntry(boardConfiguration configuration.TrelloBoardConfiguration, board *trelloLib.Board, listNames map[string]string, action *trelloLib.Action) (TrelloCardTransitionEntry, bool) {
+	if action.Data == nil || action.Data.Card == nil {
+		return TrelloCardTransitionEntry{}, false
+	}
+	fromList := action.Data.ListBefore
+	toList := action.Data.ListAfter
+	if action.Type == ActionCreateCard {
+		toList = action.Data.List
+	}
+	if toList == nil {
+		return TrelloCardTransitionEntry{}, false
+	}
+	trelloCardTransitionEntry := TrelloCardTransitionEntry{
+		Id:           action.ID,
+		Card_id:      action.Data.Card.ID,
+		Board_id:     board.ID,
+		Action_type:  action.Type,
+		To_list_id:   toList.ID,
+		To_list_name: listName(listNames, toList),
+		Date:         action.Date.UTC(),
+	}
+	if fromList != nil {
+		trelloCardTransitionEntry.From_list_id = fromList.ID
+		trelloCardTransitionEntry.From_list_name = listName(listNames, fromList)
+	}
+	trelloCardTransitionEntry.To_status = workflowStatus(boardConfiguration, trelloCardTransitionEntry.To_list_name)
+	return trelloCardTransitionEntry, true
+}
+
+// usesCustomFields returns true when the estimate or a dimension of the board is defined by a custom field.
+func usesCustomFields(boardConfiguration configuration.TrelloBoardConfiguration) bool {
+	if boardConfiguration.EstimateCustomField != "" {
+		return true
+	}
+	for _, dimension := range boardConfiguration.Dimensions {
+		if dimension.CustomField != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getListNames retrieves the names of the board lists by list ID.
+func getListNames(board *trelloLib.Board) (map[string]string, error) {
+	lists, err := board.GetLists(trelloLib.Defaults())
+	if err != nil {
+		return nil, err
+	}
+	listNames := make(map[string]string, len(lists))
+	for _, list := range lists {
+		listNames[list.ID] = list.Name
+	}
+	return listNames, nil
+}
+
+func listName(listNames map[string]string, list *trelloLib.List) string {
+	if name, found := listNames[list.ID]; found {
+		return name
+	}
+	return list.Name
+}
+
+// workflowStatus returns the workflow state of the Trello list, or an empty string when the list is not mapped to a workflow state.
+// The list names are compared case-insensitively.
+func workflowStatus(boardConfiguration configuration.TrelloBoardConfiguration, listName string) string {
+	switch {
+	case containsFold(boardConfiguration.WorkflowTodoLists, listName):
+		return StatusTodo
+	case containsFold(boardConfiguration.WorkflowInProgressLists, listName):
+		return StatusInProgress
+	case containsFold(boardConfiguration.WorkflowDoneLists, listName):
+		return StatusDone
+	}
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package trello
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	trelloLib "github.com/adlio/trello"
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+)
+
+func TestTrelloClientGetCardsFromMultipleBoards(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The last page of cards is empty.
+		if r.URL.Query().Get("before") != "" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		switch r.URL.Path {
+		case "/1/boards/board1":
+			fmt.Fprint(w, `{"id": "board1", "name": "Customer board"}`)
+		case "/1/boards/board1/cards":
+			fmt.Fprint(w, `[{"id": "card1", "name": "First card", "shortLink": "aBcD1234", "idList": "list1", "labels": [{"name": "Acme", "color": "green"}, {"name": "epic:Checkout", "color": "black"}, {"name": "P1 - urgent", "color": "red"}]}]`)
+		case "/1/boards/board1/lists":
+			fmt.Fprint(w, `[{"id": "list1", "name": "Doing"}]`)
+		case "/1/boards/board2":
+			fmt.Fprint(w, `{"id": "board2", "name": "Backlog"}`)
+		case "/1/boards/board2/cards":
+			fmt.Fprint(w, `[{"id": "card2", "name": "Second card", "shortLink": "eFgH5678", "idList": "list2", "labels": [{"name": "Acme", "color": "green"}, {"name": "Globex", "color": "purple"}]}]`)
+		case "/1/boards/board2/lists":
+			fmt.Fprint(w, `[{"id": "list2", "name": "Released"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := trelloLib.NewClient("key", "token")
+	client.BaseURL = server.URL + "/1"
+	config := configuration.Configuration{TrelloConfiguration: configuration.TrelloConfiguration{Boards: []configuration.TrelloBoardConfiguration{
+		{Id: "board1", WorkflowInProgressLists: []string{"doing"}, Dimensions: []configuration.TrelloDimensionConfiguration{
+			{Name: "customer", LabelColors: []string{"green"}},
+			{Name: "epic", LabelPrefix: "epic:"},
+			{Name: "priority", LabelRegex: `^(P\d) - `},
+		}},
+		{Id: "board2", WorkflowDoneLists: []string{"Released"}, Dimensions: []configuration.TrelloDimensionConfiguration{
+			{Name: "customer", LabelColors: []string{"purple"}},
+		}},
+	}}}
+	trelloClient := NewTrelloClient(config, logger, client)
+
+	trelloCardEntries, err := trelloClient.GetCards()
+	if err != nil {
+		t.Fatalf("Error in TrelloClient GetCards: %v", err)
+	}
+	assert.Equal(t, 2, len(trelloCardEntries), "Expected the cards of both boards")
+	assert.Equal(t, "Acme", trelloCardEntries[0].Customer, "Expected the customer from the first board customer dimension")
+	assert.Equal(t, "Customer board", trelloCardEntries[0].Board_name, "Expected the first board name")
+	assert.Equal(t, []TrelloCardDimension{{Name: "customer", Value: "Acme", Weight: 1}, {Name: "epic", Value: "Checkout", Weight: 1}, {Name: "priority", Value: "P1", Weight: 1}}, trelloCardEntries[0].Dimensions, "Expected the dimensions from the label colors, prefix and regular expression")
+	assert.Equal(t, "Globex", trelloCardEntries[1].Customer, "Expected the customer from the second board customer dimension")
+	assert.Equal(t, "board2", trelloCardEntries[1].Board_id, "Expected the second board ID")
+	assert.Equal(t, "Doing", trelloCardEntries[0].List_name, "Expected the list name of the first card")
+	assert.Equal(t, StatusInProgress, trelloCardEntries[0].Status, "Expected the workflow status of the first card")
+	assert.Equal(t, StatusDone, trelloCardEntries[1].Status, "Expected the workflow status of the second card")
+}
+
+func TestTrelloClientGetCardTransitions(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1/boards/board1":
+			fmt.Fprint(w, `{"id": "board1", "name": "Customer board"}`)
+		case "/1/boards/board1/lists":
+			fmt.Fprint(w, `[{"id": "list1", "name": "Backlog"}, {"id": "list2", "name": "Doing"}]`)
+		case "/1/boards/board1/actions":
+			assert.Equal(t, "createCard,updateCard:idList", r.URL.Query().Get("filter"), "Expected the card list actions filter")
+			fmt.Fprint(w, `[
+				{"id": "action2", "type": "updateCard", "date": "2021-02-02T10:00:00.000Z", "data": {"card": {"id": "card1"}, "listBefore": {"id": "list1", "name": "Backlog"}, "listAfter": {"id": "list2", "name": "In progress"}}},
+				{"id": "action1", "type": "createCard", "date": "2021-02-01T10:00:00.000Z", "data": {"card": {"id": "card1"}, "list": {"id": "list1", "name": "Backlog"}}}
+			]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := trelloLib.NewClient("key", "token")
+	client.BaseURL = server.URL + "/1"
+	config := configuration.Configuration{TrelloConfiguration: configuration.TrelloConfiguration{Boards: []configuration.TrelloBoardConfiguration{
+		{Id: "board1", WorkflowTodoLists: []string{"Backlog"}, WorkflowInProgressLists: []string{"Doing"}},
+	}}}
+	trelloClient := NewTrelloClient(config, logger, client)
+
+	trelloCardTransitionEntries, err := trelloClient.GetCardTransitions()
+	if err != nil {
+		t.Fatalf("Error in TrelloClient GetCardTransitions: %v", err)
+	}
+	assert.Equal(t, []TrelloCardTransitionEntry{
+		{Id: "action2", Card_id: "card1", Board_id: "board1", Action_type: ActionUpdateCard, From_list_id: "list1", From_list_name: "Backlog", To_list_id: "list2", To_list_name: "Doing", To_status: StatusInProgress, Date: time.Date(2021, time.Month(02), 02, 10, 0, 0, 0, time.UTC)},
+		{Id: "action1", Card_id: "card1", Board_id: "board1", Action_type: ActionCreateCard, To_list_id: "list1", To_list_name: "Backlog", To_status: StatusTodo, Date: time.Date(2021, time.Month(02), 01, 10, 0, 0, 0, time.UTC)},
+	}, trelloCardTransitionEntries, "Expected the card transitions with the current list names")
+}
+
+func TestTrelloClientGetLabels(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1/boards/board1":
+			fmt.Fprint(w, `{"id": "board1", "name": "Customer board"}`)
+		case "/1/boards/board1/labels":
+			fmt.Fprint(w, `[{"id": "label1", "idBoard": "board1", "name": "Acme", "color": "green"}, {"id": "label2", "idBoard": "board1", "name": "", "color": "red"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := trelloLib.NewClient("key", "token")
+	client.BaseURL = server.URL + "/1"
+	config := configuration.Configuration{TrelloConfiguration: configuration.TrelloConfiguration{Boards: []configuration.TrelloBoardConfiguration{{Id: "board1"}}}}
+	trelloClient := NewTrelloClient(config, logger, client)
+
+	trelloLabelEntries, err := trelloClient.GetLabels()
+	if err != nil {
+		t.Fatalf("Error in TrelloClient GetLabels: %v", err)
+	}
+	assert.Equal(t, []TrelloLabelEntry{
+		{Id: "label1", Board_id: "board1", Name: "Acme", Color: "green"},
+		{Id: "label2", Board_id: "board1", Name: "", Color: "red"},
+	}, trelloLabelEntries, "Expected the labels of the board")
+}
+
+func TestTrelloClientGetCardsWithCustomFields(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("before") != "" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		switch r.URL.Path {
+		case "/1/boards/board1":
+			fmt.Fprint(w, `{"id": "board1", "name": "Customer board"}`)
+		case "/1/boards/board1/customFields":
+			fmt.Fprint(w, `[
+				{"id": "field1", "name": "Customer", "type": "list", "options": [{"id": "option1", "idCustomField": "field1", "value": {"text": "Globex"}}]},
+				{"id": "field2", "name": "Estimate", "type": "number"}
+			]`)
+		case "/1/boards/board1/cards":
+			assert.Equal(t, "true", r.URL.Query().Get("customFieldItems"), "Expected the card custom field items")
+			fmt.Fprint(w, `[{"id": "card1", "name": "First card", "labels": [{"name": "Acme", "color": "green"}], "customFieldItems": [
+				{"id": "item1", "idCustomField": "field1", "idValue": "option1"},
+				{"id": "item2", "idCustomField": "field2", "value": {"number": "2.5"}}
+			]}]`)
+		case "/1/boards/board1/lists":
+			fmt.Fprint(w, `[]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := trelloLib.NewClient("key", "token")
+	client.BaseURL = server.URL + "/1"
+	config := configuration.Configuration{TrelloConfiguration: configuration.TrelloConfiguration{Boards: []configuration.TrelloBoardConfiguration{
+		{Id: "board1", EstimateCustomField: "Estimate", Dimensions: []configuration.TrelloDimensionConfiguration{
+			{Name: "customer", LabelColors: []string{"green"}, CustomField: "Customer"},
+		}},
+	}}}
+	trelloClient := NewTrelloClient(config, logger, client)
+
+	trelloCardEntries, err := trelloClient.GetCards()
+	if err != nil {
+		t.Fatalf("Error in TrelloClient GetCards: %v", err)
+	}
+	assert.Equal(t, "Globex", trelloCardEntries[0].Customer, "Expected the customer from the custom field")
+	assert.Equal(t, 2.5, trelloCardEntries[0].Estimate, "Expected the estimate from the custom field")
+}
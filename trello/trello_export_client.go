@@ -0,0 +1,104 @@
+package trello
+
+import (
+	"encoding/json"
+	"os"
+
+	trelloLib "github.com/adlio/trello"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+	"go.uber.org/zap"
+)
+
+// TrelloExportClient implements the Trello Client interface, reading the cards from a Trello board JSON export.
+type TrelloExportClient struct {
+	logger        *zap.Logger
+	configuration configuration.TrelloConfiguration
+	fileName      string
+}
+
+// trelloBoardExport struct defines the content of a Trello board JSON export.
+type trelloBoardExport struct {
+	trelloLib.Board
+	Cards        []*trelloLib.Card        `json:"cards"`
+	CustomFields []*trelloLib.CustomField `json:"customFields"`
+	Labels       []*trelloLib.Label       `json:"labels"`
+}
+
+// NewTrelloExportClient creates a new TrelloExportClient.
+func NewTrelloExportClient(config configuration.Configuration, logger *zap.Logger, fileName string) *TrelloExportClient {
+	return &TrelloExportClient{
+		logger:        logger,
+		configuration: config.TrelloConfiguration,
+		fileName:      fileName,
+	}
+}
+
+// GetCards retrieves the Trello cards from the board JSON export.
+func (trelloExportClient *TrelloExportClient) GetCards() ([]TrelloCardEntry, error) {
+	boardExport, boardConfiguration, err := trelloExportClient.readBoardExport()
+	if err != nil {
+		return nil, err
+	}
+	trelloExportClient.logger.Info("Trello board export cards", zap.String("board", boardExport.Name), zap.Int("count", len(boardExport.Cards)))
+	return newTrelloCardEntries(boardConfiguration, &boardExport.Board, boardExport.Cards, exportListNames(boardExport), boardExport.CustomFields)
+}
+
+// GetCardTransitions retrieves the creation and the list changes of the Trello cards from the actions of the board JSON export.
+// The export contains only the most recent actions of the board.
+func (trelloExportClient *TrelloExportClient) GetCardTransitions() ([]TrelloCardTransitionEntry, error) {
+	boardExport, boardConfiguration, err := trelloExportClient.readBoardExport()
+	if err != nil {
+		return nil, err
+	}
+	listNames := exportListNames(boardExport)
+	var trelloCardTransitionEntries []TrelloCardTransitionEntry
+	for _, action := range boardExport.Actions {
+		if action.Type != ActionCreateCard && action.Type != ActionUpdateCard {
+			continue
+		}
+		trelloCardTransitionEntry, found := newTrelloCardTransitionEntry(boardConfiguration, &boardExport.Board, listNames, action)
+		if found {
+			trelloCardTransitionEntries = append(trelloCardTransitionEntries, trelloCardTransitionEntry)
+		}
+	}
+	trelloExportClient.logger.Info("Trello board export card transitions", zap.String("board", boardExport.Name), zap.Int("count", len(trelloCardTransitionEntries)))
+	return trelloCardTransitionEntries, nil
+}
+
+// GetLabels retrieves the Trello labels from the board JSON export.
+func (trelloExportClient *TrelloExportClient) GetLabels() ([]TrelloLabelEntry, error) {
+	boardExport, _, err := trelloExportClient.readBoardExport()
+	if err != nil {
+		return nil, err
+	}
+	trelloExportClient.logger.Info("Trello board export labels", zap.String("board", boardExport.Name), zap.Int("count", len(boardExport.Labels)))
+	return newTrelloLabelEntries(boardExport.ID, boardExport.Labels), nil
+}
+
+// readBoardExport reads the board JSON export, together with the configuration of the board.
+// The configuration of a board not listed in "TRELLO_BOARDS" is inherited from the TrelloConfiguration.
+func (trelloExportClient *TrelloExportClient) readBoardExport() (boardExport trelloBoardExport, boardConfiguration configuration.TrelloBoardConfiguration, err error) {
+	data, err := os.ReadFile(trelloExportClient.fileName)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &boardExport)
+	if err != nil {
+		return
+	}
+	for _, board := range trelloExportClient.configuration.Boards {
+		if board.Id == boardExport.ID {
+			return boardExport, board, nil
+		}
+	}
+	boardConfiguration, err = trelloExportClient.configuration.BoardConfiguration(configuration.TrelloBoardConfiguration{Id: boardExport.ID})
+	return
+}
+
+func exportListNames(boardExport trelloBoardExport) map[string]string {
+	listNames := make(map[string]string, len(boardExport.Lists))
+	for _, list := range boardExport.Lists {
+		listNames[list.ID] = list.Name
+	}
+	return listNames
+}
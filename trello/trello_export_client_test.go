@@ -0,0 +1,108 @@
+package trello
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/sitMCella/toggl-trello-kpi/configuration"
+)
+
+const trelloBoardExportData = `{
+	"id": "board1",
+	"name": "Archived board",
+	"lists": [{"id": "list1", "name": "To Do"}, {"id": "list2", "name": "Done"}],
+	"customFields": [{"id": "field1", "name": "Estimate", "type": "number"}],
+	"labels": [{"id": "label1", "idBoard": "board1", "name": "Acme", "color": "green"}, {"id": "label2", "idBoard": "board1", "name": "Bug", "color": "red"}],
+	"cards": [
+		{"id": "card1", "name": "(3) First card", "shortLink": "aBcD1234", "idList": "list2", "closed": true,
+			"labels": [{"id": "label1", "name": "Acme", "color": "green"}, {"id": "label2", "name": "Bug", "color": "red"}],
+			"customFieldItems": [{"id": "item1", "idCustomField": "field1", "value": {"number": "5"}}]},
+		{"id": "card2", "name": "(2) Second card", "shortLink": "eFgH5678", "idList": "list1", "labels": []}
+	],
+	"actions": [
+		{"id": "action3", "type": "updateCard", "date": "2021-02-03T10:00:00.000Z", "data": {"card": {"id": "card1"}, "old": {"name": "First"}}},
+		{"id": "action2", "type": "updateCard", "date": "2021-02-02T10:00:00.000Z", "data": {"card": {"id": "card1"}, "listBefore": {"id": "list1", "name": "To Do"}, "listAfter": {"id": "list2", "name": "Done"}}},
+		{"id": "action1", "type": "createCard", "date": "2021-02-01T10:00:00.000Z", "data": {"card": {"id": "card1"}, "list": {"id": "list1", "name": "To Do"}}}
+	]
+}`
+
+func newTrelloExportClient(t *testing.T) *TrelloExportClient {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	fileName := filepath.Join(t.TempDir(), "board.json")
+	err = os.WriteFile(fileName, []byte(trelloBoardExportData), 0644)
+	if err != nil {
+		t.Fatalf("Error writing the Trello board export: %v", err)
+	}
+	config := configuration.Configuration{TrelloConfiguration: configuration.TrelloConfiguration{
+		LabelCustomerColor:  []string{"green"},
+		LabelCardTypeColor:  []string{"red"},
+		WorkflowTodoLists:   []string{"To Do"},
+		WorkflowDoneLists:   []string{"Done"},
+		DimensionPolicy:     configuration.DimensionPolicyAll,
+		EstimateCustomField: "Estimate",
+		EstimateNameRegex:   `^\s*\((\d+(?:\.\d+)?)\)`,
+	}}
+	return NewTrelloExportClient(config, logger, fileName)
+}
+
+func TestTrelloExportClientGetCards(t *testing.T) {
+	trelloExportClient := newTrelloExportClient(t)
+
+	trelloCardEntries, err := trelloExportClient.GetCards()
+	if err != nil {
+		t.Fatalf("Error in TrelloExportClient GetCards: %v", err)
+	}
+	assert.Equal(t, 2, len(trelloCardEntries), "Expected the cards of the board export")
+	expectedTrelloCardEntry := TrelloCardEntry{
+		Id:         "card1",
+		Name:       "(3) First card",
+		Closed:     true,
+		Labels:     []string{"Acme", "Bug"},
+		Label_ids:  []string{"label1", "label2"},
+		Customer:   "Acme",
+		Type:       "Bug",
+		Short_link: "aBcD1234",
+		Board_id:   "board1",
+		Board_name: "Archived board",
+		List_id:    "list2",
+		List_name:  "Done",
+		Status:     StatusDone,
+		Estimate:   5,
+		Dimensions: []TrelloCardDimension{{Name: "customer", Value: "Acme", Weight: 1}, {Name: "type", Value: "Bug", Weight: 1}},
+	}
+	assert.Equal(t, expectedTrelloCardEntry, trelloCardEntries[0], "Expected the first card with the configured label colors and the estimate custom field")
+	assert.Equal(t, StatusTodo, trelloCardEntries[1].Status, "Expected the workflow status of the second card")
+	assert.Equal(t, 2.0, trelloCardEntries[1].Estimate, "Expected the estimate from the name of the second card")
+}
+
+func TestTrelloExportClientGetCardTransitions(t *testing.T) {
+	trelloExportClient := newTrelloExportClient(t)
+
+	trelloCardTransitionEntries, err := trelloExportClient.GetCardTransitions()
+	if err != nil {
+		t.Fatalf("Error in TrelloExportClient GetCardTransitions: %v", err)
+	}
+	assert.Equal(t, []TrelloCardTransitionEntry{
+		{Id: "action2", Card_id: "card1", Board_id: "board1", Action_type: ActionUpdateCard, From_list_id: "list1", From_list_name: "To Do", To_list_id: "list2", To_list_name: "Done", To_status: StatusDone, Date: time.Date(2021, time.Month(02), 02, 10, 0, 0, 0, time.UTC)},
+		{Id: "action1", Card_id: "card1", Board_id: "board1", Action_type: ActionCreateCard, To_list_id: "list1", To_list_name: "To Do", To_status: StatusTodo, Date: time.Date(2021, time.Month(02), 01, 10, 0, 0, 0, time.UTC)},
+	}, trelloCardTransitionEntries, "Expected the list transitions of the board export")
+}
+
+func TestTrelloExportClientGetLabels(t *testing.T) {
+	trelloExportClient := newTrelloExportClient(t)
+
+	trelloLabelEntries, err := trelloExportClient.GetLabels()
+	if err != nil {
+		t.Fatalf("Error in TrelloExportClient GetLabels: %v", err)
+	}
+	assert.Equal(t, []TrelloLabelEntry{
+		{Id: "label1", Board_id: "board1", Name: "Acme", Color: "green"},
+		{Id: "label2", Board_id: "board1", Name: "Bug", Color: "red"},
+	}, trelloLabelEntries, "Expected the labels of the board export")
+}
@@ -4,11 +4,13 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bmizerany/assert"
 	"github.com/lib/pq"
 	"github.com/sitMCella/toggl-trello-kpi/application_errors"
+	"github.com/sitMCella/toggl-trello-kpi/storage"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
@@ -24,19 +26,48 @@ func (mockTrelloClient *MockTrelloClient) GetCards() ([]TrelloCardEntry, error)
 		return trelloCardEntries, nil
 	}
 	trelloCardEntry := TrelloCardEntry{
-		Id:       "45636633",
-		Name:     "Card name",
-		Closed:   false,
-		Labels:   []string{"Project name", "Customer name", "Task type"},
-		Project:  "Project name",
-		Customer: "Customer name",
-		Team:     "Team name",
-		Type:     "Task type",
+		Id:         "45636633",
+		Name:       "Card name",
+		Closed:     false,
+		Labels:     []string{"Project name", "Customer name", "Task type"},
+		Label_ids:  []string{"6a1", "6a2", "6a3"},
+		Project:    "Project name",
+		Customer:   "Customer name",
+		Team:       "Team name",
+		Type:       "Task type",
+		Short_link: "aBcD1234",
+		Board_id:   "5f1a2b3c",
+		Board_name: "Customer board",
+		List_id:    "5f1a2b3d",
+		List_name:  "Doing",
+		Status:     "in-progress",
+		Estimate:   5,
+		Dimensions: []TrelloCardDimension{{Name: "customer", Value: "Customer name", Weight: 1}, {Name: "epic", Value: "Checkout", Weight: 1}},
 	}
 	trelloCardEntries = append(trelloCardEntries, trelloCardEntry)
 	return trelloCardEntries, nil
 }
 
+func (mockTrelloClient *MockTrelloClient) GetCardTransitions() ([]TrelloCardTransitionEntry, error) {
+	trelloCardTransitionEntry := TrelloCardTransitionEntry{
+		Id:             "60a1b2c3",
+		Card_id:        "45636633",
+		Board_id:       "5f1a2b3c",
+		Action_type:    ActionUpdateCard,
+		From_list_id:   "5f1a2b3e",
+		From_list_name: "Backlog",
+		To_list_id:     "5f1a2b3d",
+		To_list_name:   "Doing",
+		To_status:      StatusInProgress,
+		Date:           time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC),
+	}
+	return []TrelloCardTransitionEntry{trelloCardTransitionEntry}, nil
+}
+
+func (mockTrelloClient *MockTrelloClient) GetLabels() ([]TrelloLabelEntry, error) {
+	return []TrelloLabelEntry{{Id: "6a2", Board_id: "5f1a2b3c", Name: "Customer name", Color: "green"}}, nil
+}
+
 func TestTrelloCreateThrowsErrorOnNilLogger(t *testing.T) {
 	mockTrelloClient := &MockTrelloClient{}
 
@@ -142,8 +173,8 @@ func TestTrelloDownloadAsCsv(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error while reading the file %s: %v", trelloEntriesFileName, err)
 	}
-	expectedData := `Id,Name,Closed,Labels,Project,Customer,Team,Type
-45636633,Card name,false,"Project name,Customer name,Task type",Project name,Customer name,Team name,Task type
+	expectedData := `Id,Name,Closed,Labels,Label_ids,Project,Customer,Team,Type,Short_link,Board_id,Board_name,List_id,List_name,Status,Estimate
+45636633,Card name,false,"Project name,Customer name,Task type","6a1,6a2,6a3",Project name,Customer name,Team name,Task type,aBcD1234,5f1a2b3c,Customer board,5f1a2b3d,Doing,in-progress,5
 `
 	assert.Equal(t, []byte(expectedData), data, "Expected same file content")
 }
@@ -191,15 +222,82 @@ func TestTrelloStoreInDatabase(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO trello_card").
-		WithArgs("45636633", "Card name", false, pq.Array([]string{"Project name", "Customer name", "Task type"}), "Project name", "Customer name", "Team name", "Task type").
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO trello_card").
+		WithArgs("45636633", "Card name", false, pq.Array([]string{"Project name", "Customer name", "Task type"}), pq.Array([]string{"6a1", "6a2", "6a3"}), "Project name", "Customer name", "Team name", "Task type", "aBcD1234", "5f1a2b3c", "Customer board", "5f1a2b3d", "Doing", "in-progress", 5.0).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+	mock.ExpectExec("DELETE FROM trello_card_dimension").
+		WithArgs(pq.Array([]string{"45636633"})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO trello_card_dimension").
+		WithArgs("45636633", "customer", "Customer name", 1.0, "45636633", "epic", "Checkout", 1.0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
 	mock.ExpectCommit()
 
-	err = trello.Store()
+	report, err := trello.Store(nil)
 	if err != nil {
 		t.Errorf("Error in Trello Store: %v", err)
 	}
+	assert.Equal(t, storage.SyncReport{Updated: 1}, report, "Expected one updated card entry")
+}
+
+func TestTrelloStoreTransitionsInDatabase(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	mockTrelloClient := &MockTrelloClient{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	trello, err := NewTrelloWithDatabaseConnection(logger, mockTrelloClient, db)
+	if err != nil {
+		t.Fatalf("Error creating Trello: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO trello_card_transition").
+		WithArgs("60a1b2c3", "45636633", "5f1a2b3c", "updateCard", "5f1a2b3e", "Backlog", "5f1a2b3d", "Doing", "in-progress", time.Date(2021, time.Month(02), 01, 9, 15, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+	mock.ExpectCommit()
+
+	report, err := trello.StoreTransitions()
+	if err != nil {
+		t.Errorf("Error in Trello StoreTransitions: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Inserted: 1}, report, "Expected one inserted card transition entry")
+}
+
+func TestTrelloStoreLabelsInDatabase(t *testing.T) {
+	logger, err := getLogger()
+	if err != nil {
+		t.Fatalf("Couldn't initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	mockTrelloClient := &MockTrelloClient{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	trello, err := NewTrelloWithDatabaseConnection(logger, mockTrelloClient, db)
+	if err != nil {
+		t.Fatalf("Error creating Trello: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO trello_label").
+		WithArgs("6a2", "5f1a2b3c", "Customer name", "green").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+	mock.ExpectCommit()
+
+	report, err := trello.StoreLabels()
+	if err != nil {
+		t.Errorf("Error in Trello StoreLabels: %v", err)
+	}
+	assert.Equal(t, storage.SyncReport{Updated: 1}, report, "Expected one renamed label entry")
 }
 
 func getLogger() (*zap.Logger, error) {